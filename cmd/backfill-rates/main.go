@@ -0,0 +1,67 @@
+// Команда backfill-rates загружает прошлые курсы валют из локального CSV в таблицу rate_history.
+// Статистика пересчитывает старые транзакции по курсу на их дату, поэтому без истории
+// она бы использовала текущий курс.
+//
+// Использование:
+//
+//	go run ./cmd/backfill-rates -file rates.csv [-per-usd]
+//
+// Формат CSV: date,currency,rate (date — YYYY-MM-DD, заголовок необязателен).
+// По умолчанию rate — курс к USD (сколько USD стоит 1 единица валюты);
+// с флагом -per-usd — сколько единиц валюты дают за 1 USD (как в exchangerate-api.com).
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/joho/godotenv"
+	_ "github.com/lib/pq"
+
+	"vue-calc/internal/config"
+	"vue-calc/internal/repository/postgres"
+	"vue-calc/internal/usecase"
+)
+
+func main() {
+	file := flag.String("file", "", "путь к CSV с курсами (date,currency,rate)")
+	perUSD := flag.Bool("per-usd", false, "rate — единиц валюты за 1 USD, а не курс к USD")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Файл .env не найден, используем системные переменные окружения")
+	}
+
+	dbCfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := sql.Open("postgres", dbCfg.URL)
+	if err != nil {
+		log.Fatal("Ошибка подключения к БД: ", err)
+	}
+	defer db.Close()
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal("Ошибка открытия файла: ", err)
+	}
+	defer f.Close()
+
+	// Fetcher не нужен: курсы берутся из файла, а не из API.
+	rateUC := usecase.NewRateUseCase(postgres.NewRateRepo(db), nil)
+	n, err := rateUC.ImportHistory(context.Background(), f, *perUSD)
+	if err != nil {
+		log.Fatalf("Загружено %d строк, ошибка: %v", n, err)
+	}
+	log.Printf("Загружено курсов: %d", n)
+}
@@ -0,0 +1,2 @@
+ALTER TABLE transactions DROP COLUMN IF EXISTS transfer_id;
+DROP TABLE IF EXISTS transfers;
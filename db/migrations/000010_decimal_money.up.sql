@@ -0,0 +1,4 @@
+-- Переводим суммы из DOUBLE PRECISION в точный NUMERIC.
+-- 4 знака после запятой покрывают любую валюту ISO 4217;
+-- ROUND убирает хвосты вроде 0.30000000000000004, накопленные во float.
+ALTER TABLE transactions ALTER COLUMN amount TYPE NUMERIC(19, 4) USING ROUND(amount::numeric, 4);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS recurring_rules (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    amount NUMERIC(19, 4) NOT NULL,
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    comment TEXT NOT NULL DEFAULT '',
+    frequency TEXT NOT NULL,
+    day_of_month INTEGER NOT NULL DEFAULT 0,
+    start_date DATE NOT NULL,
+    end_date DATE NULL,
+    next_date DATE NOT NULL,
+    paused BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL
+);
+
+-- Каждое срабатывание правила записывается ровно один раз (первичный ключ rule_id + date).
+-- skipped = TRUE — пользователь пропустил это срабатывание, транзакция не создаётся.
+CREATE TABLE IF NOT EXISTS recurring_occurrences (
+    rule_id INTEGER NOT NULL REFERENCES recurring_rules(id),
+    date DATE NOT NULL,
+    transaction_id INTEGER REFERENCES transactions(id),
+    skipped BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (rule_id, date)
+);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS budgets (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    category_id INTEGER NOT NULL REFERENCES categories(id),
+    amount NUMERIC(19, 4) NOT NULL,
+    currency TEXT NOT NULL,
+    rollover BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP NULL
+);
+
+-- Один действующий бюджет на категорию.
+CREATE UNIQUE INDEX IF NOT EXISTS budgets_category_id_key ON budgets (category_id) WHERE deleted_at IS NULL;
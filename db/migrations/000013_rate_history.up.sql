@@ -0,0 +1,12 @@
+-- История курсов: один курс на валюту на дату (последнее обновление за день).
+CREATE TABLE IF NOT EXISTS rate_history (
+    currency TEXT NOT NULL,
+    date DATE NOT NULL,
+    rate_to_usd DOUBLE PRECISION NOT NULL,
+    PRIMARY KEY (currency, date)
+);
+
+-- Текущие курсы становятся первой точкой истории.
+INSERT INTO rate_history (currency, date, rate_to_usd)
+SELECT currency, COALESCE(updated_at, CURRENT_TIMESTAMP)::date, rate_to_usd FROM rates
+ON CONFLICT DO NOTHING;
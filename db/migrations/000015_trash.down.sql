@@ -0,0 +1,22 @@
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_category_id_fkey;
+ALTER TABLE budgets ADD CONSTRAINT budgets_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id);
+
+ALTER TABLE recurring_occurrences DROP CONSTRAINT IF EXISTS recurring_occurrences_transaction_id_fkey;
+ALTER TABLE recurring_occurrences ADD CONSTRAINT recurring_occurrences_transaction_id_fkey
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id);
+ALTER TABLE recurring_occurrences DROP CONSTRAINT IF EXISTS recurring_occurrences_rule_id_fkey;
+ALTER TABLE recurring_occurrences ADD CONSTRAINT recurring_occurrences_rule_id_fkey
+    FOREIGN KEY (rule_id) REFERENCES recurring_rules(id);
+ALTER TABLE recurring_rules DROP CONSTRAINT IF EXISTS recurring_rules_account_id_fkey;
+ALTER TABLE recurring_rules ADD CONSTRAINT recurring_rules_account_id_fkey
+    FOREIGN KEY (account_id) REFERENCES accounts(id);
+
+ALTER TABLE transfers DROP CONSTRAINT IF EXISTS transfers_to_account_id_fkey;
+ALTER TABLE transfers ADD CONSTRAINT transfers_to_account_id_fkey
+    FOREIGN KEY (to_account_id) REFERENCES accounts(id);
+ALTER TABLE transfers DROP CONSTRAINT IF EXISTS transfers_from_account_id_fkey;
+ALTER TABLE transfers ADD CONSTRAINT transfers_from_account_id_fkey
+    FOREIGN KEY (from_account_id) REFERENCES accounts(id);
+
+ALTER TABLE transactions DROP COLUMN IF EXISTS deleted_with_account;
@@ -0,0 +1,31 @@
+-- Операции, удалённые вместе со счётом: при восстановлении счёта возвращаются только они,
+-- а не удалённые по одной раньше.
+ALTER TABLE transactions ADD COLUMN deleted_with_account BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE transactions t SET deleted_with_account = TRUE
+FROM accounts a
+WHERE t.account_id = a.id AND a.deleted_at IS NOT NULL AND t.deleted_at >= a.deleted_at;
+
+-- Окончательное удаление из корзины: зависимые записи удаляются или отвязываются вместе с объектом.
+ALTER TABLE transfers ALTER COLUMN from_account_id DROP NOT NULL;
+ALTER TABLE transfers ALTER COLUMN to_account_id DROP NOT NULL;
+ALTER TABLE transfers DROP CONSTRAINT IF EXISTS transfers_from_account_id_fkey;
+ALTER TABLE transfers ADD CONSTRAINT transfers_from_account_id_fkey
+    FOREIGN KEY (from_account_id) REFERENCES accounts(id) ON DELETE SET NULL;
+ALTER TABLE transfers DROP CONSTRAINT IF EXISTS transfers_to_account_id_fkey;
+ALTER TABLE transfers ADD CONSTRAINT transfers_to_account_id_fkey
+    FOREIGN KEY (to_account_id) REFERENCES accounts(id) ON DELETE SET NULL;
+
+ALTER TABLE recurring_rules DROP CONSTRAINT IF EXISTS recurring_rules_account_id_fkey;
+ALTER TABLE recurring_rules ADD CONSTRAINT recurring_rules_account_id_fkey
+    FOREIGN KEY (account_id) REFERENCES accounts(id) ON DELETE CASCADE;
+ALTER TABLE recurring_occurrences DROP CONSTRAINT IF EXISTS recurring_occurrences_rule_id_fkey;
+ALTER TABLE recurring_occurrences ADD CONSTRAINT recurring_occurrences_rule_id_fkey
+    FOREIGN KEY (rule_id) REFERENCES recurring_rules(id) ON DELETE CASCADE;
+ALTER TABLE recurring_occurrences DROP CONSTRAINT IF EXISTS recurring_occurrences_transaction_id_fkey;
+ALTER TABLE recurring_occurrences ADD CONSTRAINT recurring_occurrences_transaction_id_fkey
+    FOREIGN KEY (transaction_id) REFERENCES transactions(id) ON DELETE SET NULL;
+
+ALTER TABLE budgets DROP CONSTRAINT IF EXISTS budgets_category_id_fkey;
+ALTER TABLE budgets ADD CONSTRAINT budgets_category_id_fkey
+    FOREIGN KEY (category_id) REFERENCES categories(id) ON DELETE CASCADE;
@@ -0,0 +1,4 @@
+-- Вложенные категории: Еда > Рестораны. Если родитель удалён окончательно, подкатегория становится корневой.
+ALTER TABLE categories ADD COLUMN parent_id INTEGER NULL REFERENCES categories(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
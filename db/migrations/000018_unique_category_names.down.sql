@@ -0,0 +1 @@
+DROP INDEX IF EXISTS categories_user_id_name_key;
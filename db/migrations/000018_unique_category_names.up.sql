@@ -0,0 +1,10 @@
+-- Названия действующих категорий уникальны у пользователя без учёта регистра.
+-- Существующие дубликаты переименовываются: к названию добавляется ID категории.
+UPDATE categories c SET name = c.name || ' (' || c.id || ')'
+WHERE c.deleted_at IS NULL
+  AND EXISTS (
+    SELECT 1 FROM categories d
+    WHERE d.user_id = c.user_id AND d.deleted_at IS NULL AND lower(d.name) = lower(c.name) AND d.id < c.id
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS categories_user_id_name_key ON categories (user_id, lower(name)) WHERE deleted_at IS NULL;
@@ -0,0 +1,14 @@
+-- Вложения операций: фото чеков и PDF-документы. Сами файлы лежат в файловом хранилище
+-- под ключом storage_key; при окончательном удалении операции строки удаляются каскадом,
+-- а файлы — приложением.
+CREATE TABLE IF NOT EXISTS attachments (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    storage_key TEXT NOT NULL UNIQUE,
+    file_name TEXT NOT NULL,
+    content_type TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_transaction_id ON attachments(transaction_id);
@@ -0,0 +1,13 @@
+-- Разбивка операции на части: один чек из супермаркета — продукты, хозяйственные товары, аптека.
+-- Сумма частей равна сумме операции (проверяется приложением). У операции с частями
+-- category_id пуст: категория у каждой части своя.
+CREATE TABLE IF NOT EXISTS transaction_splits (
+    id SERIAL PRIMARY KEY,
+    transaction_id INTEGER NOT NULL REFERENCES transactions(id) ON DELETE CASCADE,
+    amount NUMERIC(19, 4) NOT NULL,
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    comment TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_transaction_id ON transaction_splits(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_splits_category_id ON transaction_splits(category_id);
@@ -0,0 +1,18 @@
+-- Совместные счета: владелец (accounts.user_id) приглашает других пользователей
+-- наблюдателями (viewer — только просмотр) или редакторами (editor — могут вести операции).
+CREATE TABLE IF NOT EXISTS account_members (
+    account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    role TEXT NOT NULL CHECK (role IN ('viewer', 'editor')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (account_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_members_user_id ON account_members(user_id);
+
+-- Кто из участников счёта создал операцию. Для существующих операций — владелец счёта.
+ALTER TABLE transactions ADD COLUMN created_by INTEGER REFERENCES users(id);
+
+UPDATE transactions t SET created_by = a.user_id
+FROM accounts a
+WHERE t.account_id = a.id;
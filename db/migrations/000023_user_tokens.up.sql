@@ -0,0 +1,15 @@
+-- Подтверждение email: NULL — адрес ещё не подтверждён.
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP NULL;
+
+-- Одноразовые токены из писем: подтверждение email и сброс пароля.
+-- Как и refresh-токены, хранятся только в виде SHA-256 хэша; used_at — токен уже предъявлен.
+CREATE TABLE IF NOT EXISTS user_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose TEXT NOT NULL CHECK (purpose IN ('verify_email', 'reset_password')),
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_tokens_user_id ON user_tokens(user_id, purpose);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS login_failures;
+DROP TABLE IF EXISTS auth_attempts;
@@ -0,0 +1,19 @@
+-- Счётчики попыток входа и регистрации для ограничителя с общим для всех реплик состоянием.
+-- key — например login:email:user@example.com или login:ip:10.0.0.1.
+CREATE TABLE IF NOT EXISTS auth_attempts (
+    key TEXT PRIMARY KEY,
+    count INTEGER NOT NULL DEFAULT 0,
+    last_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP NULL
+);
+
+-- Журнал неудачных входов. user_id — NULL, если такого email нет.
+CREATE TABLE IF NOT EXISTS login_failures (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,
+    ip TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_failures_user_id ON login_failures(user_id, created_at);
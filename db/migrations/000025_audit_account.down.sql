@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_audit_log_account_id;
+ALTER TABLE audit_log DROP COLUMN IF EXISTS account_id;
@@ -0,0 +1,16 @@
+-- Счёт, к которому относится запись журнала (для операций и счетов). По нему участники
+-- совместного счёта видят изменения друг друга; user_id по-прежнему — автор изменения.
+-- Без внешнего ключа: журнал только дополняется, а счёт может быть удалён окончательно.
+ALTER TABLE audit_log ADD COLUMN IF NOT EXISTS account_id INTEGER NULL;
+
+ALTER TABLE audit_log DISABLE TRIGGER audit_log_append_only;
+
+UPDATE audit_log SET account_id = entity_id WHERE entity_type = 'account';
+
+UPDATE audit_log l SET account_id = t.account_id
+FROM transactions t
+WHERE l.entity_type = 'transaction' AND t.id = l.entity_id;
+
+ALTER TABLE audit_log ENABLE TRIGGER audit_log_append_only;
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_account_id ON audit_log(account_id, id DESC);
@@ -1,18 +1,34 @@
 import { useAuthStore } from '@/stores/auth'
 import router from '@/router'
 
+// Одновременные запросы с истёкшим токеном ждут одного обновления:
+// refresh-токен одноразовый, повторный обмен отозвал бы сессию.
+let refreshing: Promise<boolean> | null = null
+
 export async function apiFetch(url: string, options: RequestInit = {}): Promise<Response> {
   const auth = useAuthStore()
 
-  const headers = new Headers(options.headers)
-  if (auth.token) {
-    headers.set('Authorization', `Bearer ${auth.token}`)
-  }
-  if (!headers.has('Content-Type') && options.body) {
-    headers.set('Content-Type', 'application/json')
+  const send = () => {
+    const headers = new Headers(options.headers)
+    if (auth.token) {
+      headers.set('Authorization', `Bearer ${auth.token}`)
+    }
+    if (!headers.has('Content-Type') && options.body) {
+      headers.set('Content-Type', 'application/json')
+    }
+    return fetch(url, { ...options, headers })
   }
 
-  const response = await fetch(url, { ...options, headers })
+  let response = await send()
+
+  if (response.status === 401) {
+    refreshing ??= auth.refresh().finally(() => {
+      refreshing = null
+    })
+    if (await refreshing) {
+      response = await send()
+    }
+  }
 
   if (response.status === 401) {
     auth.logout()
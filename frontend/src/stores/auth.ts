@@ -3,18 +3,46 @@ import { defineStore } from 'pinia'
 
 export const useAuthStore = defineStore('auth', () => {
   const token = ref<string | null>(localStorage.getItem('token'))
+  const refreshToken = ref<string | null>(localStorage.getItem('refresh_token'))
 
   const isAuthenticated = computed(() => !!token.value)
 
-  function setToken(newToken: string) {
+  function setTokens(newToken: string, newRefreshToken: string) {
     token.value = newToken
+    refreshToken.value = newRefreshToken
     localStorage.setItem('token', newToken)
+    localStorage.setItem('refresh_token', newRefreshToken)
+  }
+
+  // Обменять refresh-токен на новую пару; false — сессия истекла или отозвана.
+  async function refresh(): Promise<boolean> {
+    if (!refreshToken.value) return false
+
+    const response = await fetch('/api/refresh', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: refreshToken.value }),
+    })
+    if (!response.ok) return false
+
+    const data = await response.json()
+    setTokens(data.token, data.refresh_token)
+    return true
   }
 
   function logout() {
+    if (refreshToken.value) {
+      fetch('/api/logout', {
+        method: 'POST',
+        headers: { 'Content-Type': 'application/json' },
+        body: JSON.stringify({ refresh_token: refreshToken.value }),
+      }).catch(() => {})
+    }
     token.value = null
+    refreshToken.value = null
     localStorage.removeItem('token')
+    localStorage.removeItem('refresh_token')
   }
 
-  return { token, isAuthenticated, setToken, logout }
+  return { token, refreshToken, isAuthenticated, setTokens, refresh, logout }
 })
@@ -4,10 +4,9 @@ go 1.25.6
 
 require github.com/lib/pq v1.11.2
 
-require github.com/golang-migrate/migrate/v4 v4.19.1
-
 require (
-	github.com/golang-jwt/jwt/v5 v5.3.1 // indirect
-	github.com/joho/godotenv v1.5.1 // indirect
-	golang.org/x/crypto v0.48.0 // indirect
+	github.com/golang-jwt/jwt/v5 v5.3.1
+	github.com/golang-migrate/migrate/v4 v4.19.1
+	github.com/joho/godotenv v1.5.1
+	golang.org/x/crypto v0.48.0
 )
@@ -1,12 +1,30 @@
 package entity
 
+// Роли пользователя на счёте.
+const (
+	RoleOwner  = "owner"  // создатель счёта: всё, включая удаление счёта и управление участниками
+	RoleEditor = "editor" // участник: просмотр и ведение операций
+	RoleViewer = "viewer" // участник: только просмотр
+)
+
 // Account — доменная модель счёта.
 // Счёт хранит валюту и комментарий. Баланс вычисляется как сумма всех транзакций по счёту.
+// Владелец (UserID) может открыть доступ к счёту другим пользователям (см. AccountMember);
+// Role — роль запросившего пользователя.
 type Account struct {
-	ID        int     `json:"id"`
-	UserID    int     `json:"user_id"`
-	Currency  string  `json:"currency"`
-	Comment   string  `json:"comment"`
-	CreatedAt string  `json:"created_at"`
-	Balance   float64 `json:"balance"` // вычисляемое поле — сумма всех транзакций
+	ID        int    `json:"id"`
+	UserID    int    `json:"user_id"`
+	Currency  string `json:"currency"`
+	Comment   string `json:"comment"`
+	CreatedAt string `json:"created_at"`
+	Balance   Money  `json:"balance"` // вычисляемое поле — сумма всех транзакций
+	Role      string `json:"role"`
+}
+
+// AccountMember — пользователь с доступом к счёту. Владелец в списке участников — с ролью RoleOwner.
+type AccountMember struct {
+	UserID    int    `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	CreatedAt string `json:"created_at"`
 }
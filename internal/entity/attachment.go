@@ -0,0 +1,13 @@
+package entity
+
+// Attachment — вложение операции: фото чека или PDF-документ.
+// Содержимое файла хранится в FileStorage под ключом StorageKey и в API не отдаётся.
+type Attachment struct {
+	ID            int    `json:"id"`
+	TransactionID int    `json:"transaction_id"`
+	FileName      string `json:"file_name"`
+	ContentType   string `json:"content_type"`
+	Size          int64  `json:"size"`
+	StorageKey    string `json:"-"`
+	CreatedAt     string `json:"created_at"`
+}
@@ -0,0 +1,53 @@
+package entity
+
+import "encoding/json"
+
+// Типы объектов в журнале изменений.
+const (
+	AuditAccount     = "account"
+	AuditTransaction = "transaction"
+	AuditCategory    = "category"
+)
+
+// Действия в журнале изменений.
+const (
+	AuditCreate  = "create"
+	AuditUpdate  = "update"
+	AuditDelete  = "delete"
+	AuditRestore = "restore" // восстановление из корзины
+	AuditPurge   = "purge"   // окончательное удаление из корзины
+	AuditMerge   = "merge"   // слияние категорий: before — источник, after — цель
+)
+
+// AuditEntry — запись журнала изменений. Before и After — состояние объекта
+// до и после изменения (null для создания и удаления соответственно).
+// UserID — автор изменения, AccountID — счёт, к которому относится запись (для счетов и операций).
+type AuditEntry struct {
+	ID         int64           `json:"id"`
+	UserID     int             `json:"user_id"`
+	AccountID  *int            `json:"account_id"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int             `json:"entity_id"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	CreatedAt  string          `json:"created_at"`
+}
+
+// AuditFilter — параметры выборки журнала. Пустые поля не ограничивают выборку.
+type AuditFilter struct {
+	EntityType string
+	EntityID   *int
+	Action     string
+	From       string // YYYY-MM-DD включительно
+	To         string // YYYY-MM-DD включительно
+	BeforeID   int64  // только записи старше этой (для постраничного чтения)
+	Limit      int
+}
+
+// AuditPage — страница журнала, от новых записей к старым.
+// NextCursor пуст, если это последняя страница.
+type AuditPage struct {
+	Items      []AuditEntry `json:"items"`
+	NextCursor string       `json:"next_cursor"`
+}
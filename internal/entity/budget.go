@@ -0,0 +1,29 @@
+package entity
+
+// Budget — месячный лимит расходов по категории в выбранной валюте.
+// Если Rollover включён, неизрасходованный остаток (или перерасход) прошлых месяцев
+// переносится на следующий месяц.
+type Budget struct {
+	ID         int    `json:"id"`
+	UserID     int    `json:"user_id"`
+	CategoryID int    `json:"category_id"`
+	Category   string `json:"category"`
+	Amount     Money  `json:"amount"` // лимит на месяц
+	Currency   string `json:"currency"`
+	Rollover   bool   `json:"rollover"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// ErrBudgetExists — у категории уже есть бюджет.
+var ErrBudgetExists = NewError(KindConflict, "budget_exists", "Для категории уже задан бюджет")
+
+// BudgetProgress — исполнение бюджета за период (месяц), суммы — в валюте бюджета.
+type BudgetProgress struct {
+	Budget
+	Period      string  `json:"period"`       // YYYY-MM
+	CarryOver   Money   `json:"carry_over"`   // перенос с прошлых месяцев (отрицательный — перерасход)
+	Available   Money   `json:"available"`    // лимит + перенос
+	Spent       Money   `json:"spent"`        // потрачено за период
+	Remaining   Money   `json:"remaining"`    // available - spent
+	PercentUsed float64 `json:"percent_used"` // spent / available * 100
+}
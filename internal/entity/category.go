@@ -1,9 +1,14 @@
 package entity
 
 // Category — доменная модель категории расходов.
+// ParentID — родительская категория (nil — корневая), например Еда > Рестораны.
 type Category struct {
 	ID        int    `json:"id"`
 	UserID    int    `json:"user_id"`
 	Name      string `json:"name"`
+	ParentID  *int   `json:"parent_id"`
 	CreatedAt string `json:"created_at"`
 }
+
+// ErrCategoryExists — у пользователя уже есть категория с таким названием (без учёта регистра).
+var ErrCategoryExists = NewError(KindConflict, "category_exists", "Категория с таким названием уже существует")
@@ -0,0 +1,76 @@
+package entity
+
+// ErrorKind — вид доменной ошибки. По нему HTTP-слой выбирает код ответа.
+type ErrorKind string
+
+const (
+	KindNotFound     ErrorKind = "not_found"    // объект не найден или недоступен пользователю
+	KindConflict     ErrorKind = "conflict"     // противоречит текущему состоянию (дубликат, удалённый родитель)
+	KindValidation   ErrorKind = "validation"   // неверные входные данные
+	KindForbidden    ErrorKind = "forbidden"    // объект доступен, но действие запрещено
+	KindUnauthorized ErrorKind = "unauthorized" // не удалось установить пользователя
+	KindTooLarge     ErrorKind = "too_large"    // слишком большой файл или запрос
+	KindUnsupported  ErrorKind = "unsupported"  // неподдерживаемый тип содержимого
+)
+
+// Error — доменная ошибка: вид, машинный код, сообщение для пользователя
+// и ошибки по отдельным полям запроса.
+// Ошибки сравниваются по коду: errors.Is(err, ErrAccountNotFound) верно и для копии
+// с уточнённым сообщением или полями.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  map[string]string
+}
+
+// NewError — доменная ошибка вида kind с машинным кодом code.
+func NewError(kind ErrorKind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+// NewValidationError — ошибка валидации поля field запроса; сообщение относится и к полю.
+func NewValidationError(code, field, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: map[string]string{field: message}}
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Is — ошибки с одинаковым кодом считаются одной и той же ошибкой.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithField — копия ошибки с пояснением для поля field.
+func (e *Error) WithField(field, message string) *Error {
+	c := *e
+	c.Fields = make(map[string]string, len(e.Fields)+1)
+	for k, v := range e.Fields {
+		c.Fields[k] = v
+	}
+	c.Fields[field] = message
+	return &c
+}
+
+// WithDetail — копия ошибки с уточнением в сообщении: «<сообщение>: <detail>».
+func (e *Error) WithDetail(detail string) *Error {
+	c := *e
+	c.Message = e.Message + ": " + detail
+	return &c
+}
+
+// Общие ошибки «не найдено» — возвращаются репозиториями вместо sql.ErrNoRows.
+var (
+	ErrAccountNotFound      = NewError(KindNotFound, "account_not_found", "Счёт не найден")
+	ErrTransactionNotFound  = NewError(KindNotFound, "transaction_not_found", "Операция не найдена")
+	ErrCategoryNotFound     = NewError(KindNotFound, "category_not_found", "Категория не найдена")
+	ErrBudgetNotFound       = NewError(KindNotFound, "budget_not_found", "Бюджет не найден")
+	ErrRecurringNotFound    = NewError(KindNotFound, "recurring_not_found", "Правило не найдено")
+	ErrAttachmentNotFound   = NewError(KindNotFound, "attachment_not_found", "Вложение не найдено")
+	ErrMemberNotFound       = NewError(KindNotFound, "member_not_found", "Участник не найден")
+	ErrUserNotFound         = NewError(KindNotFound, "user_not_found", "Пользователь не найден")
+	ErrRateNotFound         = NewError(KindNotFound, "rate_not_found", "Курс валюты не найден")
+	ErrTrashItemNotFound    = NewError(KindNotFound, "trash_item_not_found", "Объект в корзине не найден")
+	ErrRefreshTokenNotFound = NewError(KindNotFound, "refresh_token_not_found", "Refresh-токен не найден")
+)
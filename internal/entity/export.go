@@ -0,0 +1,17 @@
+package entity
+
+// ExportFilter — фильтры выгрузки операций пользователя. Пустые поля не ограничивают выборку.
+type ExportFilter struct {
+	AccountID     *int
+	From          string // YYYY-MM-DD включительно
+	To            string // YYYY-MM-DD включительно
+	CategoryID    *int
+	Uncategorized bool
+}
+
+// ExportRow — операция для выгрузки вместе с валютой и описанием её счёта.
+type ExportRow struct {
+	Transaction
+	Currency       string
+	AccountComment string
+}
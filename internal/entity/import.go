@@ -0,0 +1,33 @@
+package entity
+
+// ImportOptions — настройки разбора CSV-выписки банка.
+// Колонки задаются именем из заголовка или номером (с нуля).
+type ImportOptions struct {
+	DateColumn       string
+	AmountColumn     string
+	CommentColumn    string // необязательно
+	CategoryColumn   string // необязательно; неизвестные категории создаются при импорте
+	Delimiter        rune   // по умолчанию ','
+	DecimalSeparator string // "." (по умолчанию) или ","
+	DateFormat       string // например "DD.MM.YYYY"; по умолчанию "YYYY-MM-DD"
+	HasHeader        bool
+	DryRun           bool // только разобрать и показать результат, ничего не сохранять
+}
+
+// ImportRow — одна разобранная строка выписки и ошибка разбора, если она есть.
+type ImportRow struct {
+	Line     int    `json:"line"`
+	Date     string `json:"date"`
+	Amount   Money  `json:"amount"`
+	Comment  string `json:"comment"`
+	Category string `json:"category"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ImportResult — итог импорта (или предпросмотра в режиме dry-run).
+type ImportResult struct {
+	DryRun   bool        `json:"dry_run"`
+	Imported int         `json:"imported"` // сколько операций сохранено
+	Errors   int         `json:"errors"`   // сколько строк не удалось разобрать
+	Rows     []ImportRow `json:"rows"`
+}
@@ -0,0 +1,179 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// moneyScale — сколько знаков после запятой хранит Money.
+// Четырёх знаков хватает для любой валюты ISO 4217 (максимум — 4 у CLF и UYW).
+const moneyScale = 4
+
+// moneyFactor — 10^moneyScale, число «единиц» Money в одной денежной единице.
+const moneyFactor = 10000
+
+// ErrMoneyOverflow — сумма не помещается в Money.
+var ErrMoneyOverflow = NewValidationError("money_overflow", "amount", "Сумма слишком большая")
+
+// Money — точная денежная сумма с фиксированной точкой.
+// Внутри — целое число десятитысячных долей, поэтому сложение и вычитание
+// не накапливают ошибок округления, как float64.
+// В JSON сериализуется числом (12.34), в БД — как NUMERIC(19,4).
+type Money int64
+
+// minorUnits — валюты, у которых число знаков после запятой по ISO 4217 отличается от 2.
+var minorUnits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0,
+	"PYG": 0, "RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// MinorUnits — число знаков после запятой для валюты по ISO 4217 (по умолчанию 2).
+func MinorUnits(currency string) int {
+	if n, ok := minorUnits[strings.ToUpper(currency)]; ok {
+		return n
+	}
+	return 2
+}
+
+// ParseMoney разбирает десятичную строку ("12.34", "-0.5", "1e3") без потери точности.
+// Знаки сверх четвёртого после запятой округляются (половина — от нуля).
+func ParseMoney(s string) (Money, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("неверная сумма: %q", s)
+	}
+	return moneyFromRat(r)
+}
+
+// moneyFromRat переводит дробь в Money с округлением половины от нуля.
+func moneyFromRat(r *big.Rat) (Money, error) {
+	scaled := new(big.Rat).Mul(r, big.NewRat(moneyFactor, 1))
+	num := new(big.Int).Abs(scaled.Num())
+	quo, rem := new(big.Int).QuoRem(num, scaled.Denom(), new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(scaled.Denom()) >= 0 {
+		quo.Add(quo, big.NewInt(1))
+	}
+	if !quo.IsInt64() {
+		return 0, ErrMoneyOverflow
+	}
+	if scaled.Sign() < 0 {
+		return Money(-quo.Int64()), nil
+	}
+	return Money(quo.Int64()), nil
+}
+
+// Round округляет сумму до минимальной единицы валюты (копейки, центы, иены...).
+func (m Money) Round(currency string) Money {
+	step := Money(math.Pow10(moneyScale - MinorUnits(currency)))
+	if step <= 1 {
+		return m
+	}
+	rem := m % step
+	switch {
+	case rem*2 >= step:
+		return m - rem + step
+	case rem*2 <= -step:
+		return m - rem - step
+	default:
+		return m - rem
+	}
+}
+
+// Convert пересчитывает сумму по курсу. Курс переводится в дробь точно,
+// результат округляется до четырёх знаков; до единиц валюты — вызовом Round.
+func (m Money) Convert(rate float64) (Money, error) {
+	r := new(big.Rat).SetFrac64(int64(m), moneyFactor)
+	rat := new(big.Rat)
+	if rat.SetFloat64(rate) == nil {
+		return 0, fmt.Errorf("неверный курс: %v", rate)
+	}
+	return moneyFromRat(r.Mul(r, rat))
+}
+
+// Abs — модуль суммы.
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// Float64 — приближённое значение для процентов и графиков. Для расчётов денег не использовать.
+func (m Money) Float64() float64 {
+	return float64(m) / moneyFactor
+}
+
+// String — десятичная запись без лишних нулей: "12.34", "-0.5", "10".
+func (m Money) String() string {
+	sign := ""
+	v := uint64(m)
+	if m < 0 {
+		sign = "-"
+		v = uint64(-m)
+	}
+	intPart := strconv.FormatUint(v/moneyFactor, 10)
+	frac := strings.TrimRight(fmt.Sprintf("%0*d", moneyScale, v%moneyFactor), "0")
+	if frac == "" {
+		return sign + intPart
+	}
+	return sign + intPart + "." + frac
+}
+
+// MarshalJSON — сумма в JSON записывается числом с точной десятичной записью.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON принимает как число (12.34), так и строку ("12.34").
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	v, err := ParseMoney(strings.Trim(s, `"`))
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Scan — чтение NUMERIC из БД (lib/pq отдаёт его как []byte с десятичной строкой).
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = 0
+		return nil
+	case []byte:
+		return m.scanString(string(v))
+	case string:
+		return m.scanString(v)
+	case int64:
+		*m = Money(v * moneyFactor)
+		return nil
+	case float64:
+		return m.scanString(strconv.FormatFloat(v, 'f', -1, 64))
+	default:
+		return fmt.Errorf("нельзя прочитать %T как Money", src)
+	}
+}
+
+func (m *Money) scanString(s string) error {
+	v, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = v
+	return nil
+}
+
+// Value — запись в БД десятичной строкой, PostgreSQL сам приведёт её к NUMERIC.
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
@@ -14,3 +14,21 @@ type ExchangeRateResponse struct {
 	Result          string             `json:"result"`
 	ConversionRates map[string]float64 `json:"conversion_rates"`
 }
+
+// RateHistory — курс валюты к USD, действовавший на дату.
+type RateHistory struct {
+	Currency  string  `json:"currency"`
+	Date      string  `json:"date"`
+	RateToUSD float64 `json:"rate_to_usd"`
+}
+
+// ProviderHealth — состояние источника курсов по последней попытке обновления.
+type ProviderHealth struct {
+	Name        string `json:"name"`
+	Priority    int    `json:"priority"` // 1 — опрашивается первым
+	Healthy     bool   `json:"healthy"`
+	LastCheck   string `json:"last_check"`
+	LastSuccess string `json:"last_success"`
+	LastError   string `json:"last_error"`
+	Failures    int    `json:"failures"` // неудачных попыток подряд
+}
@@ -0,0 +1,27 @@
+package entity
+
+// Периодичность повторяющейся операции.
+const (
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"  // раз в 7 дней, начиная со start_date
+	FrequencyMonthly = "monthly" // каждый месяц в день day_of_month
+	FrequencyYearly  = "yearly"  // каждый год в день и месяц start_date
+)
+
+// RecurringRule — правило повторяющейся операции (аренда, зарплата, подписка).
+// Фоновый планировщик создаёт по нему обычные транзакции в даты срабатывания.
+type RecurringRule struct {
+	ID         int     `json:"id"`
+	UserID     int     `json:"user_id"`
+	AccountID  int     `json:"account_id"`
+	Amount     Money   `json:"amount"`
+	CategoryID *int    `json:"category_id"`
+	Comment    string  `json:"comment"`
+	Frequency  string  `json:"frequency"`
+	DayOfMonth int     `json:"day_of_month"` // для monthly: 1..31, в коротких месяцах — последний день месяца
+	StartDate  string  `json:"start_date"`
+	EndDate    *string `json:"end_date"`
+	NextDate   string  `json:"next_date"` // ближайшая дата срабатывания, ещё не обработанная
+	Paused     bool    `json:"paused"`
+	CreatedAt  string  `json:"created_at"`
+}
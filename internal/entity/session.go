@@ -0,0 +1,21 @@
+package entity
+
+import "time"
+
+// TokenPair — токены, выдаваемые при входе и обновлении сессии.
+// Access-токен короткоживущий, refresh-токен одноразовый: при обновлении выдаётся новый.
+type TokenPair struct {
+	AccessToken  string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"` // время жизни access-токена в секундах
+}
+
+// RefreshToken — сохранённый refresh-токен (в БД хранится только хэш).
+type RefreshToken struct {
+	Hash      string
+	SessionID string
+	UserID    int
+	ExpiresAt time.Time
+	Used      bool
+	Revoked   bool // сессия токена отозвана
+}
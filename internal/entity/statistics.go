@@ -1,26 +1,41 @@
 package entity
 
 // CategoryStat — агрегированная статистика по одной категории.
+// В дереве статистики (параметр depth) Total и Count включают все подкатегории,
+// а Children — подкатегории следующего уровня.
 type CategoryStat struct {
-	CategoryID   *int    `json:"category_id"`
-	CategoryName string  `json:"category_name"`
-	Total        float64 `json:"total"`
-	Count        int     `json:"count"`
+	CategoryID   *int           `json:"category_id"`
+	CategoryName string         `json:"category_name"`
+	Total        Money          `json:"total"`
+	Count        int            `json:"count"`
+	Children     []CategoryStat `json:"children,omitempty"`
 }
 
 // DailyStat — доходы и расходы за один день (для bar chart).
 type DailyStat struct {
-	Date    string  `json:"date"`
-	Income  float64 `json:"income"`
-	Expense float64 `json:"expense"`
+	Date    string `json:"date"`
+	Income  Money  `json:"income"`
+	Expense Money  `json:"expense"`
 }
 
 // StatisticsResponse — статистика за период, все суммы пересчитаны в выбранную валюту.
 type StatisticsResponse struct {
 	Currency          string         `json:"currency"`
-	TotalIncome       float64        `json:"total_income"`
-	TotalExpense      float64        `json:"total_expense"`
+	TotalIncome       Money          `json:"total_income"`
+	TotalExpense      Money          `json:"total_expense"`
 	IncomeByCategory  []CategoryStat `json:"income_by_category"`
 	ExpenseByCategory []CategoryStat `json:"expense_by_category"`
+	IncomeByTag       []TagStat      `json:"income_by_tag"`
+	ExpenseByTag      []TagStat      `json:"expense_by_tag"`
 	DailyStats        []DailyStat    `json:"daily_stats"`
 }
+
+// StatisticsFilter — параметры статистики за период. Пустые поля не ограничивают выборку.
+type StatisticsFilter struct {
+	From      string // YYYY-MM-DD включительно
+	To        string // YYYY-MM-DD включительно
+	AccountID *int
+	Currency  string   // валюта, в которую пересчитываются суммы
+	Depth     int      // глубина дерева категорий; 0 — плоский список
+	Tags      []string // только операции со всеми перечисленными тегами
+}
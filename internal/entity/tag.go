@@ -0,0 +1,16 @@
+package entity
+
+// Tag — тег пользователя с числом операций, на которых он стоит.
+type Tag struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// TagStat — агрегированная статистика по одному тегу.
+// Операция с несколькими тегами учитывается в каждом из них.
+type TagStat struct {
+	Tag   string `json:"tag"`
+	Total Money  `json:"total"`
+	Count int    `json:"count"`
+}
@@ -2,12 +2,71 @@ package entity
 
 // Transaction — доменная модель операции (транзакции) по счёту.
 // Положительное значение amount — пополнение, отрицательное — списание.
+// TransferID заполнен, если операция — одна из двух половин перевода между счетами.
+// Tags — теги операции; при изменении nil (поле не передано) оставляет теги как есть.
+// AttachmentCount — число вложений (чеков, документов); только для чтения.
+// Splits — разбивка операции по категориям; если части есть, CategoryID пуст,
+// а сумма частей равна Amount. При изменении nil оставляет разбивку как есть, пустой список — убирает её.
+// CreatedBy — участник совместного счёта, создавший операцию.
 type Transaction struct {
-	ID         int     `json:"id"`
-	AccountID  int     `json:"account_id"`
-	Amount     float64 `json:"amount"`
-	Comment    string  `json:"comment"`
-	CategoryID *int    `json:"category_id"`
-	Category   string  `json:"category"`
-	CreatedAt  string  `json:"created_at"`
+	ID              int                `json:"id"`
+	AccountID       int                `json:"account_id"`
+	Amount          Money              `json:"amount"`
+	Comment         string             `json:"comment"`
+	CategoryID      *int               `json:"category_id"`
+	Category        string             `json:"category"`
+	Tags            []string           `json:"tags"`
+	AttachmentCount int                `json:"attachment_count"`
+	Splits          []TransactionSplit `json:"splits"`
+	TransferID      *int               `json:"transfer_id"`
+	CreatedBy       *int               `json:"created_by"`
+	CreatedAt       string             `json:"created_at"`
+}
+
+// TransactionSplit — часть операции со своей суммой, категорией и комментарием.
+// Знак суммы части совпадает со знаком суммы операции.
+type TransactionSplit struct {
+	ID         int    `json:"id"`
+	Amount     Money  `json:"amount"`
+	CategoryID *int   `json:"category_id"`
+	Category   string `json:"category"`
+	Comment    string `json:"comment"`
+}
+
+// Варианты сортировки истории операций.
+const (
+	SortDateDesc   = "date_desc" // сначала новые (по умолчанию)
+	SortDateAsc    = "date_asc"
+	SortAmountDesc = "amount_desc"
+	SortAmountAsc  = "amount_asc"
+)
+
+// TransactionFilter — параметры выборки истории операций по счёту.
+// Пустые поля не ограничивают выборку.
+type TransactionFilter struct {
+	From          string   // дата "с" включительно, YYYY-MM-DD
+	To            string   // дата "по" включительно, YYYY-MM-DD
+	CategoryID    *int     // операции категории, в том числе разбитые, у которых в ней есть часть
+	Uncategorized bool     // только операции без категории (или с частью без категории)
+	MinAmount     *Money   // нижняя граница суммы по модулю
+	MaxAmount     *Money   // верхняя граница суммы по модулю
+	Sign          string   // "income" — только пополнения, "expense" — только списания
+	Comment       string   // подстрока комментария, без учёта регистра
+	Tags          []string // операции со всеми перечисленными тегами
+	Sort          string   // одна из констант Sort*
+	Limit         int
+	Cursor        *TransactionCursor // позиция, после которой начинается страница
+}
+
+// TransactionCursor — позиция в отсортированной выборке: значение ключа сортировки
+// (дата или сумма) и ID последней операции предыдущей страницы.
+type TransactionCursor struct {
+	Value string
+	ID    int
+}
+
+// TransactionPage — одна страница истории операций.
+type TransactionPage struct {
+	Items      []Transaction `json:"items"`
+	NextCursor string        `json:"next_cursor"` // пусто, если дальше страниц нет
 }
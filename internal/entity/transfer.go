@@ -0,0 +1,21 @@
+package entity
+
+// Transfer — перевод денег между двумя счетами пользователя.
+// В БД хранится как пара связанных транзакций: списание со счёта-источника
+// и пополнение счёта-получателя (возможно, в другой валюте).
+type Transfer struct {
+	ID            int         `json:"id"`
+	UserID        int         `json:"user_id"`
+	FromAccountID int         `json:"from_account_id"`
+	ToAccountID   int         `json:"to_account_id"`
+	Amount        Money       `json:"amount"` // сумма списания в валюте счёта-источника
+	Rate          float64     `json:"rate"`   // курс пересчёта; 0 — взять из таблицы rates
+	Comment       string      `json:"comment"`
+	CreatedAt     string      `json:"created_at"`
+	From          Transaction `json:"from_transaction"`
+	To            Transaction `json:"to_transaction"`
+}
+
+// ErrTransferImmutable — половину перевода нельзя изменить отдельно: суммы и даты обеих половин
+// связаны курсом пересчёта. Перевод удаляют (удаляются обе половины) и создают заново.
+var ErrTransferImmutable = NewError(KindConflict, "transfer_immutable", "Операцию перевода нельзя изменить — удалите перевод и создайте заново")
@@ -0,0 +1,28 @@
+package entity
+
+// Trash — мягко удалённые объекты пользователя, которые можно восстановить или удалить окончательно.
+type Trash struct {
+	Accounts     []TrashedAccount     `json:"accounts"`
+	Categories   []TrashedCategory    `json:"categories"`
+	Transactions []TrashedTransaction `json:"transactions"`
+}
+
+// TrashedAccount — удалённый счёт. Balance и Transactions — по операциям,
+// удалённым вместе со счётом (они вернутся при восстановлении).
+type TrashedAccount struct {
+	Account
+	Transactions int    `json:"transactions"`
+	DeletedAt    string `json:"deleted_at"`
+}
+
+// TrashedCategory — удалённая категория.
+type TrashedCategory struct {
+	Category
+	DeletedAt string `json:"deleted_at"`
+}
+
+// TrashedTransaction — операция, удалённая отдельно (не вместе со счётом).
+type TrashedTransaction struct {
+	Transaction
+	DeletedAt string `json:"deleted_at"`
+}
@@ -1,9 +1,23 @@
 package entity
 
+// Назначения одноразовых токенов, отправляемых пользователю по email.
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+)
+
 // User — доменная модель пользователя.
 type User struct {
-	ID           int    `json:"id"`
-	Email        string `json:"email"`
-	PasswordHash string `json:"-"`
-	CreatedAt    string `json:"created_at"`
+	ID            int    `json:"id"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+	PasswordHash  string `json:"-"`
+	CreatedAt     string `json:"created_at"`
 }
+
+var (
+	// ErrEmailTaken — пользователь с таким email уже зарегистрирован.
+	ErrEmailTaken = NewError(KindConflict, "email_taken", "Пользователь с таким email уже существует")
+	// ErrInvalidLink — токен из письма не найден, истёк или уже использован.
+	ErrInvalidLink = NewError(KindValidation, "invalid_link", "Ссылка недействительна или устарела")
+)
@@ -0,0 +1,44 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvWriter — выгрузка в CSV. Листы идут друг за другом, разделённые пустой строкой.
+type csvWriter struct {
+	w      *csv.Writer
+	sheets int
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Sheet(name string, columns []string) error {
+	if c.sheets > 0 {
+		if err := c.w.Write(nil); err != nil {
+			return err
+		}
+	}
+	c.sheets++
+	return c.w.Write(columns)
+}
+
+func (c *csvWriter) Row(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = cellText(v)
+	}
+	if err := c.w.Write(record); err != nil {
+		return err
+	}
+	// Отдаём строки клиенту по мере чтения из БД, а не одним куском в конце.
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	c.w.Flush()
+	return c.w.Error()
+}
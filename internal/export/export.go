@@ -0,0 +1,70 @@
+// Пакет export — потоковая запись табличных данных в CSV, XLSX и JSON.
+// Все форматы реализуют usecase.TableWriter: строки пишутся в io.Writer сразу,
+// без накопления всей выгрузки в памяти.
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"vue-calc/internal/entity"
+)
+
+// Форматы выгрузки.
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+	FormatJSON = "json"
+)
+
+// Writer — табличный вывод: набор листов, у каждого — колонки и строки.
+type Writer interface {
+	Sheet(name string, columns []string) error
+	Row(values ...interface{}) error
+	Close() error
+}
+
+// NewWriter — writer для формата format. Второе значение — Content-Type ответа.
+func NewWriter(format string, w io.Writer) (Writer, string, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w), "text/csv; charset=utf-8", nil
+	case FormatXLSX:
+		return newXLSXWriter(w), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+	case FormatJSON:
+		return newJSONWriter(w), "application/json", nil
+	default:
+		return nil, "", fmt.Errorf("неизвестный формат %q", format)
+	}
+}
+
+// cellText — текстовое представление значения ячейки.
+func cellText(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *int:
+		if v == nil {
+			return ""
+		}
+		return fmt.Sprint(*v)
+	case entity.Money:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// isNumber — значение пишется в таблицу числом, а не строкой.
+func isNumber(v interface{}) bool {
+	switch v := v.(type) {
+	case int, int64, float64, entity.Money:
+		return true
+	case *int:
+		return v != nil
+	default:
+		return false
+	}
+}
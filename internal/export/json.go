@@ -0,0 +1,82 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// jsonWriter — выгрузка в JSON: объект {"лист": [{"колонка": значение, ...}, ...], ...}.
+type jsonWriter struct {
+	w       io.Writer
+	columns []string
+	sheets  int
+	rows    int
+	err     error
+}
+
+func newJSONWriter(w io.Writer) *jsonWriter {
+	return &jsonWriter{w: w}
+}
+
+func (j *jsonWriter) write(s string) {
+	if j.err == nil {
+		_, j.err = io.WriteString(j.w, s)
+	}
+}
+
+func (j *jsonWriter) writeJSON(v interface{}) {
+	if j.err != nil {
+		return
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		j.err = err
+		return
+	}
+	_, j.err = j.w.Write(b)
+}
+
+func (j *jsonWriter) Sheet(name string, columns []string) error {
+	if j.sheets == 0 {
+		j.write("{")
+	} else {
+		j.write("],")
+	}
+	j.writeJSON(name)
+	j.write(":[")
+	j.sheets++
+	j.rows = 0
+	j.columns = columns
+	return j.err
+}
+
+func (j *jsonWriter) Row(values ...interface{}) error {
+	if j.rows > 0 {
+		j.write(",")
+	}
+	j.rows++
+	j.write("{")
+	for i, column := range j.columns {
+		if i > 0 {
+			j.write(",")
+		}
+		j.writeJSON(column)
+		j.write(":")
+		if i < len(values) {
+			j.writeJSON(values[i])
+		} else {
+			j.write("null")
+		}
+	}
+	j.write("}")
+	return j.err
+}
+
+func (j *jsonWriter) Close() error {
+	if j.sheets == 0 {
+		j.write("{}")
+	} else {
+		j.write("]}")
+	}
+	return j.err
+}
@@ -0,0 +1,135 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// xlsxWriter — выгрузка в XLSX (Office Open XML) без сторонних библиотек.
+// Файл — zip-архив; лист пишется в архив построчно, поэтому память не растёт
+// с числом строк. Оглавление книги (workbook.xml, [Content_Types].xml)
+// дописывается в Close, когда известны все листы.
+type xlsxWriter struct {
+	zw     *zip.Writer
+	sheet  io.Writer
+	sheets []string
+	err    error
+}
+
+func newXLSXWriter(w io.Writer) *xlsxWriter {
+	return &xlsxWriter{zw: zip.NewWriter(w)}
+}
+
+func (x *xlsxWriter) write(s string) {
+	if x.err == nil {
+		_, x.err = io.WriteString(x.sheet, s)
+	}
+}
+
+// closeSheet — дописать хвост текущего листа.
+func (x *xlsxWriter) closeSheet() {
+	if x.sheet != nil {
+		x.write("</sheetData></worksheet>")
+		x.sheet = nil
+	}
+}
+
+func (x *xlsxWriter) Sheet(name string, columns []string) error {
+	if x.err != nil {
+		return x.err
+	}
+	x.closeSheet()
+
+	// Имя листа в Excel — до 31 символа и без []:*?/\.
+	name = strings.NewReplacer("[", "", "]", "", ":", "", "*", "", "?", "", "/", "", `\`, "").Replace(name)
+	if r := []rune(name); len(r) > 31 {
+		name = string(r[:31])
+	}
+	x.sheets = append(x.sheets, name)
+
+	x.sheet, x.err = x.zw.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(x.sheets)))
+	if x.err != nil {
+		return x.err
+	}
+	x.write(xml.Header)
+	x.write(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(columns))
+	for i, c := range columns {
+		header[i] = c
+	}
+	return x.Row(header...)
+}
+
+func (x *xlsxWriter) Row(values ...interface{}) error {
+	if x.sheet == nil {
+		return fmt.Errorf("xlsx: строка до создания листа")
+	}
+	x.write("<row>")
+	for _, v := range values {
+		text := escapeXML(cellText(v))
+		if isNumber(v) {
+			x.write("<c><v>" + text + "</v></c>")
+		} else {
+			x.write(`<c t="inlineStr"><is><t xml:space="preserve">` + text + "</t></is></c>")
+		}
+	}
+	x.write("</row>")
+	return x.err
+}
+
+func (x *xlsxWriter) Close() error {
+	if len(x.sheets) == 0 && x.err == nil {
+		// Пустая книга Excel невалидна — нужен хотя бы один лист.
+		if err := x.Sheet("Sheet1", nil); err != nil {
+			return err
+		}
+	}
+	x.closeSheet()
+	if x.err != nil {
+		return x.err
+	}
+
+	var types, sheets, rels strings.Builder
+	for i, name := range x.sheets {
+		n := i + 1
+		fmt.Fprintf(&types, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&sheets, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+
+	files := []struct{ name, body string }{
+		{"[Content_Types].xml", `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+			`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+			`<Default Extension="xml" ContentType="application/xml"/>` +
+			`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+			types.String() + `</Types>`},
+		{"_rels/.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets>` + sheets.String() + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			rels.String() + `</Relationships>`},
+	}
+	for _, f := range files {
+		w, err := x.zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, xml.Header+f.body); err != nil {
+			return err
+		}
+	}
+	return x.zw.Close()
+}
+
+// escapeXML — экранировать текст ячейки для вставки в XML.
+func escapeXML(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -1,11 +1,8 @@
 package handler
 
 import (
-	"database/sql"
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
 	"vue-calc/internal/entity"
 	"vue-calc/internal/usecase"
 )
@@ -20,134 +17,214 @@ func NewAccountHandler(uc *usecase.AccountUseCase) *AccountHandler {
 	return &AccountHandler{uc: uc}
 }
 
-// HandleList — обработка запросов к /api/accounts (список + создание).
-func (h *AccountHandler) HandleList(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	userID, ok := UserIDFromContext(r.Context())
+// List — GET /api/accounts: все счета пользователя.
+func (h *AccountHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
 	if !ok {
-		http.Error(w, `{"error": "Требуется авторизация"}`, http.StatusUnauthorized)
 		return
 	}
 
-	switch r.Method {
-	case http.MethodGet:
-		h.getAll(w, userID)
-	case http.MethodPost:
-		h.create(w, r, userID)
-	default:
-		http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
+	accounts, err := h.uc.GetAll(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
 	}
+	json.NewEncoder(w).Encode(accounts)
 }
 
-// HandleByID — обработка запросов к /api/accounts/{id}.
-func (h *AccountHandler) HandleByID(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	userID, ok := UserIDFromContext(r.Context())
+// Create — POST /api/accounts: создать новый счёт.
+func (h *AccountHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
 	if !ok {
-		http.Error(w, `{"error": "Требуется авторизация"}`, http.StatusUnauthorized)
 		return
 	}
 
-	idStr := strings.TrimPrefix(r.URL.Path, "/api/accounts/")
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		http.Error(w, `{"error": "Неверный ID"}`, http.StatusBadRequest)
+	var account entity.Account
+	if err := decodeJSON(r, &account); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	switch r.Method {
-	case http.MethodGet:
-		h.getByID(w, id, userID)
-	case http.MethodPut:
-		h.updateComment(w, r, id, userID)
-	case http.MethodDelete:
-		h.delete(w, id, userID)
-	default:
-		http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
-	}
-}
+	account.UserID = userID
 
-// getAll — получить все счета пользователя.
-func (h *AccountHandler) getAll(w http.ResponseWriter, userID int) {
-	accounts, err := h.uc.GetAll(userID)
+	account, err := h.uc.Create(r.Context(), account)
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка получения счетов"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
-	json.NewEncoder(w).Encode(accounts)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(account)
 }
 
-// create — создать новый счёт.
-func (h *AccountHandler) create(w http.ResponseWriter, r *http.Request, userID int) {
-	var account entity.Account
-	if err := json.NewDecoder(r.Body).Decode(&account); err != nil {
-		http.Error(w, `{"error": "Неверный формат JSON"}`, http.StatusBadRequest)
+// Get — GET /api/accounts/{id}: один счёт.
+func (h *AccountHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
 		return
 	}
-
-	account.UserID = userID
-
-	account, err := h.uc.Create(account)
+	id, err := pathID(r, "id", "id", "Неверный ID")
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка создания счёта"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
+	account, err := h.uc.GetByID(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
 	json.NewEncoder(w).Encode(account)
 }
 
-// getByID — получить один счёт по ID.
-func (h *AccountHandler) getByID(w http.ResponseWriter, id, userID int) {
-	account, err := h.uc.GetByID(id, userID)
-	if err == sql.ErrNoRows {
-		http.Error(w, `{"error": "Счёт не найден"}`, http.StatusNotFound)
+// UpdateComment — PUT /api/accounts/{id}: обновить комментарий счёта.
+func (h *AccountHandler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
 		return
 	}
+	id, err := pathID(r, "id", "id", "Неверный ID")
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка получения счёта"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
-	json.NewEncoder(w).Encode(account)
-}
 
-// updateComment — обновить комментарий счёта.
-func (h *AccountHandler) updateComment(w http.ResponseWriter, r *http.Request, id, userID int) {
 	var body struct {
 		Comment string `json:"comment"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		http.Error(w, `{"error": "Неверный формат JSON"}`, http.StatusBadRequest)
+	if err := decodeJSON(r, &body); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	if err := h.uc.UpdateComment(id, userID, body.Comment); err != nil {
-		http.Error(w, `{"error": "Счёт не найден"}`, http.StatusNotFound)
+	if err := h.uc.UpdateComment(r.Context(), id, userID, body.Comment); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	account, err := h.uc.GetByID(id, userID)
+	account, err := h.uc.GetByID(r.Context(), id, userID)
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка получения счёта"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 	json.NewEncoder(w).Encode(account)
 }
 
-// delete — удалить счёт по ID.
-func (h *AccountHandler) delete(w http.ResponseWriter, id, userID int) {
-	rowsAffected, err := h.uc.Delete(id, userID)
+// Delete — DELETE /api/accounts/{id}: удалить счёт.
+func (h *AccountHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID")
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка удаления счёта"}`, http.StatusInternalServerError)
+		writeError(w, err)
+		return
+	}
+
+	if err := h.uc.Delete(r.Context(), id, userID); err != nil {
+		writeError(w, err)
 		return
 	}
+	w.WriteHeader(http.StatusNoContent)
+}
 
-	if rowsAffected == 0 {
-		http.Error(w, `{"error": "Счёт не найден"}`, http.StatusNotFound)
+// ListMembers — GET /api/accounts/{id}/members: владелец и участники счёта.
+func (h *AccountHandler) ListMembers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID счёта")
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
+	members, err := h.uc.GetMembers(r.Context(), id, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(members)
+}
+
+// SetMember — POST /api/accounts/{id}/members {email, role}: пригласить пользователя на счёт
+// или сменить его роль.
+func (h *AccountHandler) SetMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID счёта")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var body struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}
+	if err := decodeJSON(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	member, err := h.uc.SetMember(r.Context(), id, userID, body.Email, body.Role)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(member)
+}
+
+// RemoveMember — DELETE /api/accounts/{id}/members/{userId}: закрыть участнику доступ к счёту
+// (владелец — любому, участник — себе).
+func (h *AccountHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID счёта")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	memberID, err := pathID(r, "userId", "user_id", "Неверный ID пользователя")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.uc.RemoveMember(r.Context(), id, userID, memberID); err != nil {
+		writeError(w, err)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// accountAccess — пользователь из контекста и счёт {id} из пути, к которому у него есть доступ;
+// для изменений (write) нужна роль владельца или редактора. При отказе отправляет ответ
+// и возвращает false.
+func accountAccess(w http.ResponseWriter, r *http.Request, accountUC *usecase.AccountUseCase, write bool) (accountID, userID int, ok bool) {
+	if userID, ok = requireUser(w, r); !ok {
+		return 0, 0, false
+	}
+	accountID, err := pathID(r, "id", "account_id", "Неверный ID счёта")
+	if err != nil {
+		writeError(w, err)
+		return 0, 0, false
+	}
+
+	if write {
+		err = accountUC.CanEdit(r.Context(), accountID, userID)
+	} else {
+		_, err = accountUC.GetRole(r.Context(), accountID, userID)
+	}
+	if err != nil {
+		writeError(w, err)
+		return 0, 0, false
+	}
+	return accountID, userID, true
+}
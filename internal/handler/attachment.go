@@ -0,0 +1,139 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"vue-calc/internal/usecase"
+)
+
+// AttachmentHandler — HTTP-обработчик вложений операций (чеки, документы).
+type AttachmentHandler struct {
+	uc        *usecase.AttachmentUseCase
+	accountUC *usecase.AccountUseCase
+}
+
+// NewAttachmentHandler — конструктор обработчика вложений.
+func NewAttachmentHandler(uc *usecase.AttachmentUseCase, accountUC *usecase.AccountUseCase) *AttachmentHandler {
+	return &AttachmentHandler{uc: uc, accountUC: accountUC}
+}
+
+// List — GET /api/accounts/{id}/transactions/{txId}/attachments: вложения операции.
+func (h *AttachmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	accountID, txID, ok := h.transaction(w, r, false)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.uc.GetByTransaction(r.Context(), txID, accountID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(attachments)
+}
+
+// Upload — POST /api/accounts/{id}/transactions/{txId}/attachments (multipart, поле file):
+// загрузить файл (изображение или PDF до 10 МБ) к операции.
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	accountID, txID, ok := h.transaction(w, r, true)
+	if !ok {
+		return
+	}
+
+	// Запас сверх размера файла — на заголовки multipart.
+	r.Body = http.MaxBytesReader(w, r.Body, usecase.MaxAttachmentSize+1<<20)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, usecase.ErrAttachmentTooLarge)
+			return
+		}
+		writeError(w, errInvalidMultipart)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, errFileRequired)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.uc.Upload(r.Context(), txID, accountID, header.Filename, file)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// Download — GET /api/accounts/{id}/transactions/{txId}/attachments/{attachmentId}:
+// отдать содержимое вложения с исходным именем файла.
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	accountID, txID, ok := h.transaction(w, r, false)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "attachmentId", "id", "Неверный ID вложения")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	attachment, content, err := h.uc.Open(r.Context(), id, txID, accountID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(w, content); err != nil {
+		log.Println("Ошибка отправки вложения:", err)
+	}
+}
+
+// Delete — DELETE /api/accounts/{id}/transactions/{txId}/attachments/{attachmentId}: удалить вложение.
+func (h *AttachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	accountID, txID, ok := h.transaction(w, r, true)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "attachmentId", "id", "Неверный ID вложения")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.uc.Delete(r.Context(), id, txID, accountID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// transaction — счёт {id} и операция {txId} из пути с проверкой доступа к счёту
+// (см. accountAccess). При отказе отправляет ответ и возвращает false.
+func (h *AttachmentHandler) transaction(w http.ResponseWriter, r *http.Request, write bool) (accountID, txID int, ok bool) {
+	accountID, _, ok = accountAccess(w, r, h.accountUC, write)
+	if !ok {
+		return 0, 0, false
+	}
+	txID, err := pathID(r, "txId", "transaction_id", "Неверный ID операции")
+	if err != nil {
+		writeError(w, err)
+		return 0, 0, false
+	}
+	return accountID, txID, true
+}
@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"vue-calc/internal/entity"
+	"vue-calc/internal/usecase"
+)
+
+// AuditHandler — HTTP-обработчик журнала изменений.
+type AuditHandler struct {
+	uc *usecase.AuditUseCase
+}
+
+// NewAuditHandler — конструктор обработчика журнала изменений.
+func NewAuditHandler(uc *usecase.AuditUseCase) *AuditHandler {
+	return &AuditHandler{uc: uc}
+}
+
+// Handle — GET /api/audit: журнал изменений пользователя и его совместных счетов, от новых записей к старым.
+// Параметры: entity_type (account|transaction|category), entity_id,
+// action (create|update|delete|restore|purge|merge), from, to (YYYY-MM-DD), limit, cursor.
+func (h *AuditHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := entity.AuditFilter{
+		EntityType: q.Get("entity_type"),
+		Action:     q.Get("action"),
+		From:       q.Get("from"),
+		To:         q.Get("to"),
+	}
+	if err := validateDates(q, "from", "to"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if v := q.Get("entity_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, invalidParam("entity_id", "Неверный entity_id"))
+			return
+		}
+		filter.EntityID = &id
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			writeError(w, invalidParam("limit", "Неверный limit"))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	page, err := h.uc.Get(r.Context(), userID, filter, q.Get("cursor"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(page)
+}
@@ -2,21 +2,35 @@ package handler
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
+	"strings"
 
+	"vue-calc/internal/entity"
 	"vue-calc/internal/usecase"
 )
 
 // AuthHandler — HTTP-обработчик для регистрации и входа.
 type AuthHandler struct {
 	uc *usecase.AuthUseCase
+	// trustProxy — сервер стоит за обратным прокси: IP клиента берётся из X-Forwarded-For.
+	trustProxy bool
 }
 
 // NewAuthHandler — конструктор.
-func NewAuthHandler(uc *usecase.AuthUseCase) *AuthHandler {
-	return &AuthHandler{uc: uc}
+func NewAuthHandler(uc *usecase.AuthUseCase, trustProxy bool) *AuthHandler {
+	return &AuthHandler{uc: uc, trustProxy: trustProxy}
 }
 
+// Ошибки обязательных полей в запросах авторизации.
+var (
+	errCredentialsRequired  = entity.NewError(entity.KindValidation, "credentials_required", "Email и пароль обязательны")
+	errRefreshTokenRequired = entity.NewValidationError("refresh_token_required", "refresh_token", "Требуется refresh_token")
+	errTokenRequired        = entity.NewValidationError("token_required", "token", "Требуется token")
+	errEmailRequired        = entity.NewValidationError("email_required", "email", "Требуется email")
+	errResetRequired        = entity.NewError(entity.KindValidation, "reset_required", "Токен и новый пароль обязательны")
+)
+
 // authRequest — тело запроса на регистрацию/вход.
 type authRequest struct {
 	Email    string `json:"email"`
@@ -27,25 +41,20 @@ type authRequest struct {
 func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if r.Method != http.MethodPost {
-		http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
-		return
-	}
-
 	var req authRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "Неверный формат JSON"}`, http.StatusBadRequest)
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
 		return
 	}
 
 	if req.Email == "" || req.Password == "" {
-		http.Error(w, `{"error": "Email и пароль обязательны"}`, http.StatusBadRequest)
+		writeError(w, errCredentialsRequired)
 		return
 	}
 
-	user, err := h.uc.Register(req.Email, req.Password)
+	user, err := h.uc.Register(r.Context(), req.Email, req.Password, h.clientIP(r))
 	if err != nil {
-		http.Error(w, `{"error": "Пользователь с таким email уже существует"}`, http.StatusConflict)
+		writeError(w, err)
 		return
 	}
 
@@ -57,22 +66,177 @@ func (h *AuthHandler) HandleRegister(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if r.Method != http.MethodPost {
-		http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
+	var req authRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	var req authRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error": "Неверный формат JSON"}`, http.StatusBadRequest)
+	tokens, err := h.uc.Login(r.Context(), req.Email, req.Password, h.clientIP(r))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// refreshRequest — тело запроса на обновление токенов и выход.
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// HandleRefresh — POST /api/refresh. Обменивает refresh-токен на новую пару токенов.
+func (h *AuthHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req refreshRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.RefreshToken == "" {
+		writeError(w, errRefreshTokenRequired)
 		return
 	}
 
-	token, err := h.uc.Login(req.Email, req.Password)
+	tokens, err := h.uc.Refresh(r.Context(), req.RefreshToken)
 	if err != nil {
-		http.Error(w, `{"error": "Неверный email или пароль"}`, http.StatusUnauthorized)
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// HandleLogout — POST /api/logout. Отзывает сессию refresh-токена вместе с её access-токенами.
+func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req refreshRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.RefreshToken == "" {
+		writeError(w, errRefreshTokenRequired)
+		return
+	}
+
+	if err := h.uc.Logout(r.Context(), req.RefreshToken); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tokenRequest — тело запроса с токеном из письма (и новым паролем для сброса).
+type tokenRequest struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// HandleVerifyEmail — POST /api/verify-email. Подтверждает email по токену из письма.
+func (h *AuthHandler) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req tokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Token == "" {
+		writeError(w, errTokenRequired)
 		return
 	}
 
-	json.NewEncoder(w).Encode(map[string]string{"token": token})
+	if err := h.uc.VerifyEmail(r.Context(), req.Token); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleResendVerification — POST /api/verify-email/resend (требует авторизации).
+// Отправляет новую ссылку подтверждения на email текущего пользователя.
+func (h *AuthHandler) HandleResendVerification(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.uc.ResendVerification(r.Context(), userID); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleForgotPassword — POST /api/password/forgot. Отправляет ссылку сброса пароля.
+// Ответ одинаковый для зарегистрированных и неизвестных адресов.
+func (h *AuthHandler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req authRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Email == "" {
+		writeError(w, errEmailRequired)
+		return
+	}
+
+	if err := h.uc.RequestPasswordReset(r.Context(), req.Email); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// HandleResetPassword — POST /api/password/reset. Задаёт новый пароль по токену из письма
+// и отзывает все сессии пользователя.
+func (h *AuthHandler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req tokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Token == "" || req.Password == "" {
+		writeError(w, errResetRequired)
+		return
+	}
+
+	if err := h.uc.ResetPassword(r.Context(), req.Token, req.Password); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clientIP — IP-адрес клиента. За доверенным прокси — последний адрес из X-Forwarded-For
+// (его добавил сам прокси; более ранние клиент мог подставить).
+func (h *AuthHandler) clientIP(r *http.Request) string {
+	if h.trustProxy {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			addrs := strings.Split(forwarded, ",")
+			if ip := strings.TrimSpace(addrs[len(addrs)-1]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
 }
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"vue-calc/internal/entity"
+	"vue-calc/internal/usecase"
+)
+
+// BudgetHandler — HTTP-обработчик для бюджетов по категориям.
+type BudgetHandler struct {
+	uc *usecase.BudgetUseCase
+}
+
+// NewBudgetHandler — конструктор обработчика бюджетов.
+func NewBudgetHandler(uc *usecase.BudgetUseCase) *BudgetHandler {
+	return &BudgetHandler{uc: uc}
+}
+
+// List — GET /api/budgets: все бюджеты пользователя.
+func (h *BudgetHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	budgets, err := h.uc.GetAll(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(budgets)
+}
+
+// Create — POST /api/budgets: создать бюджет.
+func (h *BudgetHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var budget entity.Budget
+	if err := decodeJSON(r, &budget); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	budget.UserID = userID
+
+	budget, err := h.uc.Create(r.Context(), budget)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(budget)
+}
+
+// Update — PUT /api/budgets/{id}: изменить бюджет.
+func (h *BudgetHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID бюджета")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var budget entity.Budget
+	if err := decodeJSON(r, &budget); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	budget.ID = id
+	budget.UserID = userID
+
+	budget, err = h.uc.Update(r.Context(), budget)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(budget)
+}
+
+// Delete — DELETE /api/budgets/{id}: удалить бюджет.
+func (h *BudgetHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID бюджета")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.uc.Delete(r.Context(), id, userID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Progress — GET /api/budgets/progress?period=YYYY-MM: потрачено, остаток и процент за месяц.
+func (h *BudgetHandler) Progress(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	progress, err := h.uc.GetProgress(r.Context(), userID, r.URL.Query().Get("period"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(progress)
+}
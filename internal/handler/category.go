@@ -3,8 +3,7 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
-	"strings"
+
 	"vue-calc/internal/entity"
 	"vue-calc/internal/usecase"
 )
@@ -19,80 +18,137 @@ func NewCategoryHandler(uc *usecase.CategoryUseCase) *CategoryHandler {
 	return &CategoryHandler{uc: uc}
 }
 
-// Handle — обработка запросов к /api/categories и /api/categories/{id}.
-func (h *CategoryHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	userID, ok := UserIDFromContext(r.Context())
+// List — GET /api/categories: все категории пользователя.
+func (h *CategoryHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
 	if !ok {
-		http.Error(w, `{"error": "Требуется авторизация"}`, http.StatusUnauthorized)
 		return
 	}
 
-	// Проверяем, есть ли ID в URL: /api/categories/{id}
-	path := strings.TrimPrefix(r.URL.Path, "/api/categories")
-	path = strings.TrimPrefix(path, "/")
+	categories, err := h.uc.GetAll(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(categories)
+}
 
-	if path != "" && r.Method == http.MethodDelete {
-		id, err := strconv.Atoi(path)
-		if err != nil {
-			http.Error(w, `{"error": "Неверный ID категории"}`, http.StatusBadRequest)
-			return
-		}
-		h.delete(w, id, userID)
+// Create — POST /api/categories: создать новую категорию.
+func (h *CategoryHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
 		return
 	}
 
-	switch r.Method {
-	case http.MethodGet:
-		h.getAll(w, userID)
-	case http.MethodPost:
-		h.create(w, r, userID)
-	default:
-		http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
+	var category entity.Category
+	if err := decodeJSON(r, &category); err != nil {
+		writeError(w, err)
+		return
 	}
-}
 
-// getAll — получить все категории пользователя.
-func (h *CategoryHandler) getAll(w http.ResponseWriter, userID int) {
-	categories, err := h.uc.GetAll(userID)
+	category.UserID = userID
+
+	category, err := h.uc.Create(r.Context(), category)
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка получения категорий"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
-	json.NewEncoder(w).Encode(categories)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(category)
 }
 
-// create — создать новую категорию.
-func (h *CategoryHandler) create(w http.ResponseWriter, r *http.Request, userID int) {
-	var category entity.Category
-	if err := json.NewDecoder(r.Body).Decode(&category); err != nil {
-		http.Error(w, `{"error": "Неверный формат JSON"}`, http.StatusBadRequest)
+// categoryUpdateRequest — тело PUT /api/categories/{id}. Отсутствующее поле не меняется;
+// parent_id: null — сделать категорию корневой.
+type categoryUpdateRequest struct {
+	Name     *string         `json:"name"`
+	ParentID json.RawMessage `json:"parent_id"`
+}
+
+// Update — PUT /api/categories/{id}: переименовать категорию и/или перенести под другого родителя.
+// Тело — {"name": ..., "parent_id": ...}; без parent_id родитель остаётся прежним.
+func (h *CategoryHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID категории")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	var req categoryUpdateRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeError(w, err)
 		return
 	}
+	changes := usecase.CategoryChanges{Name: req.Name}
+	if req.ParentID != nil {
+		changes.SetParent = true
+		if err := json.Unmarshal(req.ParentID, &changes.ParentID); err != nil {
+			writeError(w, invalidParam("parent_id", "Неверный ID родительской категории"))
+			return
+		}
+	}
 
-	if strings.TrimSpace(category.Name) == "" {
-		http.Error(w, `{"error": "Название категории обязательно"}`, http.StatusBadRequest)
+	category, err := h.uc.Update(r.Context(), id, userID, changes)
+	if err != nil {
+		writeError(w, err)
 		return
 	}
+	json.NewEncoder(w).Encode(category)
+}
 
-	category.UserID = userID
+// mergeRequest — тело POST /api/categories/{id}/merge.
+type mergeRequest struct {
+	TargetID int `json:"target_id"`
+}
 
-	category, err := h.uc.Create(category)
+// Merge — POST /api/categories/{id}/merge: слить категорию в target_id —
+// операции переходят в цель, категория удаляется.
+func (h *CategoryHandler) Merge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID категории")
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка создания категории"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(category)
+	var req mergeRequest
+	if err := decodeJSON(r, &req); err != nil || req.TargetID == 0 {
+		writeError(w, invalidParam("target_id", "Требуется target_id"))
+		return
+	}
+
+	target, moved, err := h.uc.Merge(r.Context(), id, req.TargetID, userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"category":           target,
+		"moved_transactions": moved,
+	})
 }
 
-// delete — удалить категорию по ID.
-func (h *CategoryHandler) delete(w http.ResponseWriter, id, userID int) {
-	err := h.uc.Delete(id, userID)
+// Delete — DELETE /api/categories/{id}: удалить категорию.
+func (h *CategoryHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID категории")
 	if err != nil {
-		http.Error(w, `{"error": "Категория не найдена"}`, http.StatusNotFound)
+		writeError(w, err)
+		return
+	}
+
+	if err := h.uc.Delete(r.Context(), id, userID); err != nil {
+		writeError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
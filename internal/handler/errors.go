@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"vue-calc/internal/entity"
+	"vue-calc/internal/usecase"
+)
+
+// errorResponse — тело ответа с ошибкой: сообщение для пользователя, машинный код
+// и пояснения по полям запроса (для ошибок валидации).
+type errorResponse struct {
+	Error      string            `json:"error"`
+	Code       string            `json:"code"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	RetryAfter int               `json:"retry_after,omitempty"`
+}
+
+// statusByKind — HTTP-статус для каждого вида доменной ошибки.
+var statusByKind = map[entity.ErrorKind]int{
+	entity.KindNotFound:     http.StatusNotFound,
+	entity.KindConflict:     http.StatusConflict,
+	entity.KindValidation:   http.StatusBadRequest,
+	entity.KindForbidden:    http.StatusForbidden,
+	entity.KindUnauthorized: http.StatusUnauthorized,
+	entity.KindTooLarge:     http.StatusRequestEntityTooLarge,
+	entity.KindUnsupported:  http.StatusUnsupportedMediaType,
+}
+
+// Ошибки разбора запроса, общие для всех обработчиков.
+var (
+	errUnauthorized = entity.NewError(entity.KindUnauthorized, "unauthorized", "Требуется авторизация")
+	errInvalidJSON  = entity.NewError(entity.KindValidation, "invalid_json", "Неверный формат JSON")
+	errInvalidURL   = entity.NewError(entity.KindValidation, "invalid_url", "Неверный URL")
+	errNotFound     = entity.NewError(entity.KindNotFound, "not_found", "Не найдено")
+	errBodyTooLarge = entity.NewError(entity.KindTooLarge, "body_too_large", "Слишком большой запрос")
+
+	errPeriodRequired = entity.NewError(entity.KindValidation, "period_required", "Параметры from и to обязательны")
+
+	errInvalidMultipart = entity.NewError(entity.KindValidation, "invalid_multipart", "Неверный запрос: ожидается multipart/form-data")
+	errFileRequired     = entity.NewValidationError("file_required", "file", "Файл file обязателен")
+)
+
+// invalidParam — ошибка валидации параметра запроса field с сообщением message.
+func invalidParam(field, message string) error {
+	return entity.NewError(entity.KindValidation, "invalid_param", message).WithField(field, message)
+}
+
+// pathID — числовой параметр пути name (из шаблона маршрута, например {id});
+// при ошибке — ошибка валидации поля field с сообщением message.
+func pathID(r *http.Request, name, field, message string) (int, error) {
+	id, err := strconv.Atoi(r.PathValue(name))
+	if err != nil {
+		return 0, invalidParam(field, message)
+	}
+	return id, nil
+}
+
+// requireUser — user_id, который AuthMiddleware положил в контекст. Если его нет,
+// отвечает 401 и возвращает false.
+func requireUser(w http.ResponseWriter, r *http.Request) (int, bool) {
+	userID, ok := UserIDFromContext(r.Context())
+	if !ok {
+		writeError(w, errUnauthorized)
+	}
+	return userID, ok
+}
+
+// decodeJSON — разобрать JSON-тело запроса в v: errInvalidJSON, если тело не разбирается,
+// и errBodyTooLarge, если оно больше лимита BodyLimitMiddleware.
+func decodeJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return errBodyTooLarge
+		}
+		return errInvalidJSON
+	}
+	return nil
+}
+
+// validateDates — query-параметры names пустые или даты в формате YYYY-MM-DD.
+func validateDates(q url.Values, names ...string) error {
+	for _, name := range names {
+		d := q.Get(name)
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", d); err != nil {
+			return invalidParam(name, "Неверная дата, ожидается YYYY-MM-DD")
+		}
+	}
+	return nil
+}
+
+// writeError — единственное место, где ошибка превращается в HTTP-ответ.
+// Доменная ошибка (*entity.Error) отдаётся со статусом по её виду, кодом и полями;
+// превышение числа попыток — 429 с Retry-After; истёкший таймаут запроса — 503;
+// прочие ошибки — 500 без подробностей (подробности пишутся в лог).
+func writeError(w http.ResponseWriter, err error) {
+	if errors.Is(err, context.Canceled) {
+		// Клиент отключился — отвечать некому.
+		return
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		log.Println("Таймаут запроса:", err)
+		writeErrorResponse(w, http.StatusServiceUnavailable, errorResponse{
+			Error: "Сервер не успел обработать запрос, повторите позже",
+			Code:  "timeout",
+		})
+		return
+	}
+
+	var tooMany *usecase.TooManyAttemptsError
+	if errors.As(err, &tooMany) {
+		seconds := int(math.Ceil(tooMany.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+		writeErrorResponse(w, http.StatusTooManyRequests, errorResponse{
+			Error:      "Слишком много попыток, повторите через " + strconv.Itoa(seconds) + " с",
+			Code:       "too_many_attempts",
+			RetryAfter: seconds,
+		})
+		return
+	}
+
+	var domainErr *entity.Error
+	if errors.As(err, &domainErr) {
+		status, ok := statusByKind[domainErr.Kind]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		writeErrorResponse(w, status, errorResponse{
+			Error:  domainErr.Message,
+			Code:   domainErr.Code,
+			Fields: domainErr.Fields,
+		})
+		return
+	}
+
+	log.Println("Внутренняя ошибка:", err)
+	writeErrorResponse(w, http.StatusInternalServerError, errorResponse{
+		Error: "Внутренняя ошибка сервера",
+		Code:  "internal",
+	})
+}
+
+// methodNotAllowed — ответ 405 на неподдерживаемый метод.
+func methodNotAllowed(w http.ResponseWriter) {
+	writeErrorResponse(w, http.StatusMethodNotAllowed, errorResponse{
+		Error: "Метод не поддерживается",
+		Code:  "method_not_allowed",
+	})
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, body errorResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
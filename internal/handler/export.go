@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"vue-calc/internal/entity"
+	"vue-calc/internal/export"
+	"vue-calc/internal/usecase"
+)
+
+// ExportHandler — HTTP-обработчик выгрузки операций и статистики в файлы.
+type ExportHandler struct {
+	uc *usecase.ExportUseCase
+}
+
+// NewExportHandler — конструктор обработчика выгрузки.
+func NewExportHandler(uc *usecase.ExportUseCase) *ExportHandler {
+	return &ExportHandler{uc: uc}
+}
+
+// HandleTransactions — GET /api/export/transactions?format=csv|xlsx|json&account_id=...&from=...&to=...&category_id=...
+// category_id может быть числом или "uncategorized".
+func (h *ExportHandler) HandleTransactions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	filter := entity.ExportFilter{From: q.Get("from"), To: q.Get("to")}
+	if err := validateDates(q, "from", "to"); err != nil {
+		writeError(w, err)
+		return
+	}
+	if v := q.Get("account_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, invalidParam("account_id", "Неверный account_id"))
+			return
+		}
+		filter.AccountID = &id
+	}
+	if v := q.Get("category_id"); v == "uncategorized" {
+		filter.Uncategorized = true
+	} else if v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, invalidParam("category_id", "Неверный category_id"))
+			return
+		}
+		filter.CategoryID = &id
+	}
+
+	file, tw, ok := h.writer(w, q.Get("format"), export.FormatCSV, "transactions")
+	if !ok {
+		return
+	}
+	file.fail(h.uc.Transactions(r.Context(), userID, filter, tw), "Ошибка выгрузки операций:")
+}
+
+// HandleStatistics — GET /api/export/statistics?format=xlsx|csv|json&from=...&to=...&currency=...&account_id=...
+func (h *ExportHandler) HandleStatistics(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if from == "" || to == "" {
+		writeError(w, errPeriodRequired)
+		return
+	}
+	if err := validateDates(q, "from", "to"); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	currency := q.Get("currency")
+	if currency == "" {
+		currency = "USD"
+	}
+
+	var accountID *int
+	if v := q.Get("account_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			writeError(w, invalidParam("account_id", "Неверный account_id"))
+			return
+		}
+		accountID = &id
+	}
+
+	file, tw, ok := h.writer(w, q.Get("format"), export.FormatXLSX, "statistics")
+	if !ok {
+		return
+	}
+	file.fail(h.uc.Statistics(r.Context(), userID, from, to, accountID, currency, tw), "Ошибка выгрузки статистики:")
+}
+
+// writer — выбрать формат выгрузки. Заголовки скачивания файла выставляются при первой записи
+// (см. downloadWriter).
+func (h *ExportHandler) writer(w http.ResponseWriter, format, defaultFormat, name string) (*downloadWriter, export.Writer, bool) {
+	if format == "" {
+		format = defaultFormat
+	}
+	file := &downloadWriter{w: w, filename: name + "." + format}
+	tw, contentType, err := export.NewWriter(format, file)
+	if err != nil {
+		writeError(w, invalidParam("format", "Неверный format: csv, xlsx или json"))
+		return nil, nil, false
+	}
+	file.contentType = contentType
+	return file, tw, true
+}
+
+// downloadWriter — тело ответа-файла. Заголовки Content-Type и Content-Disposition отправляются
+// вместе с первыми байтами файла, поэтому ошибка до начала выгрузки уходит обычным JSON-ответом.
+type downloadWriter struct {
+	w           http.ResponseWriter
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (d *downloadWriter) Write(b []byte) (int, error) {
+	if !d.started {
+		d.started = true
+		d.w.Header().Set("Content-Type", d.contentType)
+		d.w.Header().Set("Content-Disposition", `attachment; filename="`+d.filename+`"`)
+	}
+	return d.w.Write(b)
+}
+
+// fail — обработать ошибку выгрузки: если файл ещё не начат, ответить ошибкой;
+// иначе заголовки и часть файла уже отправлены — статус поменять нельзя, только залогировать.
+func (d *downloadWriter) fail(err error, logPrefix string) {
+	if err == nil {
+		return
+	}
+	if !d.started {
+		writeError(d.w, err)
+		return
+	}
+	log.Println(logPrefix, err)
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"unicode/utf8"
+	"vue-calc/internal/entity"
+	"vue-calc/internal/usecase"
+)
+
+// maxImportSize — максимальный размер загружаемой выписки.
+const maxImportSize = 10 << 20
+
+// ImportHandler — HTTP-обработчик импорта банковских выписок.
+type ImportHandler struct {
+	uc        *usecase.ImportUseCase
+	accountUC *usecase.AccountUseCase
+}
+
+// NewImportHandler — конструктор обработчика импорта.
+func NewImportHandler(uc *usecase.ImportUseCase, accountUC *usecase.AccountUseCase) *ImportHandler {
+	return &ImportHandler{uc: uc, accountUC: accountUC}
+}
+
+// Handle — POST /api/accounts/{id}/import (multipart/form-data).
+// Поля: file — CSV-файл; date_column, amount_column, comment_column, category_column —
+// имя колонки из заголовка или её номер с нуля; delimiter, decimal_separator, date_format,
+// has_header (true/false), dry_run (true/false).
+func (h *ImportHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	accountID, userID, ok := accountAccess(w, r, h.accountUC, true)
+	if !ok {
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		writeError(w, errInvalidMultipart.WithDetail("файл до 10 МБ"))
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, errFileRequired)
+		return
+	}
+	defer file.Close()
+
+	opts := entity.ImportOptions{
+		DateColumn:       r.FormValue("date_column"),
+		AmountColumn:     r.FormValue("amount_column"),
+		CommentColumn:    r.FormValue("comment_column"),
+		CategoryColumn:   r.FormValue("category_column"),
+		DecimalSeparator: r.FormValue("decimal_separator"),
+		DateFormat:       r.FormValue("date_format"),
+		HasHeader:        r.FormValue("has_header") == "true",
+		DryRun:           r.FormValue("dry_run") == "true",
+	}
+	if d := r.FormValue("delimiter"); d != "" {
+		if d == `\t` {
+			d = "\t"
+		}
+		if utf8.RuneCountInString(d) != 1 {
+			writeError(w, invalidParam("delimiter", "delimiter должен быть одним символом"))
+			return
+		}
+		opts.Delimiter, _ = utf8.DecodeRuneInString(d)
+	}
+
+	result, err := h.uc.Import(r.Context(), accountID, userID, file, opts)
+	if errors.Is(err, usecase.ErrImportRows) {
+		// Возвращаем разбор по строкам, чтобы пользователь увидел ошибки.
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if !opts.DryRun {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -2,17 +2,41 @@ package handler
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"log/slog"
 	"net/http"
-	"os"
+	"runtime/debug"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"vue-calc/internal/usecase"
 )
 
+// Middleware — обёртка над обработчиком: проверки, логирование, изменение контекста запроса.
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Chain — middleware, применяющее middlewares по порядку: первое оборачивает все остальные
+// и первым видит запрос.
+func Chain(middlewares ...Middleware) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
 // contextKey — тип для ключей контекста (избегаем коллизий).
 type contextKey string
 
-const userIDKey contextKey = "user_id"
+const (
+	userIDKey    contextKey = "user_id"
+	requestIDKey contextKey = "request_id"
+)
 
 // UserIDFromContext — извлекает user_id из контекста запроса.
 func UserIDFromContext(ctx context.Context) (int, bool) {
@@ -20,53 +44,220 @@ func UserIDFromContext(ctx context.Context) (int, bool) {
 	return id, ok
 }
 
-// AuthMiddleware — middleware для проверки JWT-токена.
-// Извлекает user_id из токена и помещает его в context.
-func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
+// RequestIDFromContext — ID запроса, выданный RequestIDMiddleware ("" — не выдан).
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// AuthMiddleware — middleware для проверки access-токена.
+// Токен проверяется через AuthUseCase (подпись, срок и активность сессии),
+// user_id из токена помещается в context. Ответ по умолчанию — JSON.
+func AuthMiddleware(uc *usecase.AuthUseCase) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				writeError(w, errUnauthorized)
+				return
+			}
+
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			if tokenString == authHeader {
+				writeError(w, usecase.ErrInvalidToken)
+				return
+			}
+
+			userID, err := uc.Authenticate(r.Context(), tokenString)
+			if err != nil {
+				writeError(w, err)
+				return
+			}
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, `{"error": "Требуется авторизация"}`, http.StatusUnauthorized)
-			return
+			ctx := context.WithValue(r.Context(), userIDKey, userID)
+			next(w, r.WithContext(ctx))
 		}
+	}
+}
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			http.Error(w, `{"error": "Неверный формат токена"}`, http.StatusUnauthorized)
-			return
+// TimeoutMiddleware — ограничение времени обработки запроса: через timeout контекст запроса
+// отменяется, и незавершённые запросы к БД прерываются. Контекст отменяется и при отключении
+// клиента. timeout <= 0 — без ограничения.
+func TimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if timeout <= 0 {
+			return next
 		}
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// maxRequestIDLength — ID запроса от клиента длиннее этого заменяется своим.
+const maxRequestIDLength = 64
 
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "vue-calc-default-secret"
+// RequestIDMiddleware — ID запроса для логов: берётся из заголовка X-Request-ID
+// (если клиент или прокси его передал) или генерируется. Возвращается в том же заголовке.
+func RequestIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID(id) {
+			id = newRequestID()
 		}
+		w.Header().Set("X-Request-ID", id)
+		next(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	}
+}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
-			}
-			return []byte(secret), nil
-		})
-		if err != nil || !token.Valid {
-			http.Error(w, `{"error": "Невалидный токен"}`, http.StatusUnauthorized)
-			return
+// validRequestID — непустой ID из латиницы, цифр, '-', '_' и '.' не длиннее maxRequestIDLength
+// (ID попадает в логи и заголовки ответа как есть).
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID — случайный ID запроса: 16 hex-символов.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// AccessLogMiddleware — структурированный журнал запросов: метод, путь, статус, размер ответа,
+// длительность, адрес клиента и ID запроса.
+func AccessLogMiddleware(logger *slog.Logger) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			sw := &statusWriter{ResponseWriter: w}
+			next(sw, r)
+
+			logger.LogAttrs(r.Context(), slog.LevelInfo, "request",
+				slog.String("request_id", RequestIDFromContext(r.Context())),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", sw.Status()),
+				slog.Int64("bytes", sw.bytes),
+				slog.Duration("duration", time.Since(start)),
+				slog.String("remote_addr", r.RemoteAddr),
+			)
 		}
+	}
+}
+
+// statusWriter — ResponseWriter, запоминающий статус и размер ответа.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap — исходный ResponseWriter для http.ResponseController.
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Status — отправленный статус (200, если обработчик ничего не записал).
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// RecoverMiddleware — паника в обработчике превращается в ответ 500 и запись в лог со стеком,
+// а не в оборванное соединение.
+func RecoverMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				// Обработчик сам прервал ответ — сервер обработает это штатно.
+				panic(p)
+			}
+			log.Printf("Паника при обработке %s %s (запрос %s): %v\n%s",
+				r.Method, r.URL.Path, RequestIDFromContext(r.Context()), p, debug.Stack())
+			writeErrorResponse(w, http.StatusInternalServerError, errorResponse{
+				Error: "Внутренняя ошибка сервера",
+				Code:  "internal",
+			})
+		}()
+		next(w, r)
+	}
+}
 
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			http.Error(w, `{"error": "Невалидный токен"}`, http.StatusUnauthorized)
-			return
+// corsMaxAge — сколько секунд браузер может не повторять preflight-запрос.
+const corsMaxAge = 10 * 60
+
+// CORSMiddleware — разрешить запросы к API со страниц из origins ("*" — с любых).
+// Preflight-запросы (OPTIONS) получают ответ 204 и дальше не передаются.
+// Без origins заголовки CORS не выставляются: фронтенд ходит через тот же origin.
+func CORSMiddleware(origins []string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if len(origins) == 0 {
+			return next
 		}
+		allowAll := slices.Contains(origins, "*")
+		return func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !(allowAll || slices.Contains(origins, origin)) {
+				next(w, r)
+				return
+			}
 
-		userIDFloat, ok := claims["user_id"].(float64)
-		if !ok {
-			http.Error(w, `{"error": "Невалидный токен"}`, http.StatusUnauthorized)
-			return
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Expose-Headers", "Content-Disposition, Retry-After, X-Request-ID")
+
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE")
+				h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, X-Request-ID")
+				h.Set("Access-Control-Max-Age", strconv.Itoa(corsMaxAge))
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next(w, r)
 		}
+	}
+}
 
-		ctx := context.WithValue(r.Context(), userIDKey, int(userIDFloat))
-		next(w, r.WithContext(ctx))
+// BodyLimitMiddleware — ограничить тело запроса n байтами; при превышении чтение тела
+// завершается ошибкой, и decodeJSON отвечает 413.
+func BodyLimitMiddleware(n int64) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			r.Body = http.MaxBytesReader(w, r.Body, n)
+			next(w, r)
+		}
 	}
 }
@@ -21,16 +21,38 @@ func NewRateHandler(uc *usecase.RateUseCase) *RateHandler {
 func (h *RateHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if r.Method != http.MethodGet {
-		http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
+	rates, err := h.uc.GetAll(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(rates)
+}
+
+// HandleHistory обрабатывает GET /api/rates/history?currency=...&from=...&to=... — история курсов.
+func (h *RateHandler) HandleHistory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	q := r.URL.Query()
+	from, to := q.Get("from"), q.Get("to")
+	if err := validateDates(q, "from", "to"); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	rates, err := h.uc.GetAll()
+	history, err := h.uc.GetHistory(r.Context(), q.Get("currency"), from, to)
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка получения курсов"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	json.NewEncoder(w).Encode(rates)
+	json.NewEncoder(w).Encode(history)
+}
+
+// HandleProviders обрабатывает GET /api/rates/providers — состояние источников курсов.
+func (h *RateHandler) HandleProviders(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(h.uc.ProvidersHealth())
 }
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"vue-calc/internal/entity"
+	"vue-calc/internal/usecase"
+)
+
+// RecurringHandler — HTTP-обработчик для правил повторяющихся операций.
+type RecurringHandler struct {
+	uc *usecase.RecurringUseCase
+}
+
+// NewRecurringHandler — конструктор обработчика повторяющихся операций.
+func NewRecurringHandler(uc *usecase.RecurringUseCase) *RecurringHandler {
+	return &RecurringHandler{uc: uc}
+}
+
+// List — GET /api/recurring: все правила пользователя.
+func (h *RecurringHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := h.uc.GetAll(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(rules)
+}
+
+// Create — POST /api/recurring: создать правило.
+func (h *RecurringHandler) Create(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var rule entity.RecurringRule
+	if err := decodeJSON(r, &rule); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	rule.UserID = userID
+
+	rule, err := h.uc.Create(r.Context(), rule)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// Get — GET /api/recurring/{id}: одно правило.
+func (h *RecurringHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, userID, ok := h.rule(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.uc.GetByID(r.Context(), id, userID)
+	h.writeRule(w, rule, err)
+}
+
+// Update — PUT /api/recurring/{id}: изменить правило.
+func (h *RecurringHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, userID, ok := h.rule(w, r)
+	if !ok {
+		return
+	}
+
+	var rule entity.RecurringRule
+	if err := decodeJSON(r, &rule); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	rule.ID = id
+	rule.UserID = userID
+
+	rule, err := h.uc.Update(r.Context(), rule)
+	h.writeRule(w, rule, err)
+}
+
+// Delete — DELETE /api/recurring/{id}: удалить правило.
+func (h *RecurringHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, userID, ok := h.rule(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.uc.Delete(r.Context(), id, userID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Pause — POST /api/recurring/{id}/pause: приостановить правило.
+func (h *RecurringHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	id, userID, ok := h.rule(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.uc.Pause(r.Context(), id, userID)
+	h.writeRule(w, rule, err)
+}
+
+// Resume — POST /api/recurring/{id}/resume: возобновить правило.
+func (h *RecurringHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	id, userID, ok := h.rule(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.uc.Resume(r.Context(), id, userID)
+	h.writeRule(w, rule, err)
+}
+
+// Skip — POST /api/recurring/{id}/skip: пропустить одно срабатывание правила,
+// тело {"date": "YYYY-MM-DD"}.
+func (h *RecurringHandler) Skip(w http.ResponseWriter, r *http.Request) {
+	id, userID, ok := h.rule(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Date string `json:"date"`
+	}
+	if err := decodeJSON(r, &body); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := h.uc.Skip(r.Context(), id, userID, body.Date); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// rule — ID правила из пути и пользователь из контекста. При ошибке отправляет ответ
+// и возвращает false.
+func (h *RecurringHandler) rule(w http.ResponseWriter, r *http.Request) (id, userID int, ok bool) {
+	if userID, ok = requireUser(w, r); !ok {
+		return 0, 0, false
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID правила")
+	if err != nil {
+		writeError(w, err)
+		return 0, 0, false
+	}
+	return id, userID, true
+}
+
+// writeRule — ответить правилом или ошибкой.
+func (h *RecurringHandler) writeRule(w http.ResponseWriter, rule entity.RecurringRule, err error) {
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(rule)
+}
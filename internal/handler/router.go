@@ -0,0 +1,51 @@
+package handler
+
+import "net/http"
+
+// Router — http.ServeMux с шаблонами маршрутов вида "GET /api/accounts/{id}"
+// и ответами 404 и 405 в общем JSON-формате ошибок.
+type Router struct {
+	*http.ServeMux
+}
+
+// NewRouter — пустой маршрутизатор.
+func NewRouter() *Router {
+	return &Router{ServeMux: http.NewServeMux()}
+}
+
+// ServeHTTP — передать запрос обработчику маршрута. Если маршрут не найден, ответ ServeMux
+// (текстовые 404 или 405 с заголовком Allow) заменяется JSON-ошибкой.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if _, pattern := rt.Handler(r); pattern == "" {
+		w = &unmatchedWriter{ResponseWriter: w}
+	}
+	rt.ServeMux.ServeHTTP(w, r)
+}
+
+// unmatchedWriter — ResponseWriter для запросов без маршрута: вместо текстового ответа
+// ServeMux отправляет JSON-ошибку с тем же статусом.
+type unmatchedWriter struct {
+	http.ResponseWriter
+	replaced bool
+}
+
+func (w *unmatchedWriter) WriteHeader(status int) {
+	switch status {
+	case http.StatusNotFound:
+		w.replaced = true
+		writeError(w.ResponseWriter, errNotFound)
+	case http.StatusMethodNotAllowed:
+		w.replaced = true
+		methodNotAllowed(w.ResponseWriter)
+	default:
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *unmatchedWriter) Write(b []byte) (int, error) {
+	if w.replaced {
+		// Текст ошибки от ServeMux уже заменён JSON.
+		return len(b), nil
+	}
+	return w.ResponseWriter.Write(b)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"vue-calc/internal/entity"
 	"vue-calc/internal/usecase"
 )
 
@@ -17,18 +18,14 @@ func NewStatisticsHandler(uc *usecase.StatisticsUseCase) *StatisticsHandler {
 	return &StatisticsHandler{uc: uc}
 }
 
-// Handle — обработка GET /api/statistics?from=...&to=...&account_id=...&currency=...
+// Handle — обработка GET /api/statistics?from=...&to=...&account_id=...&currency=...&depth=...&tag=...
+// depth — глубина дерева категорий (итоги включают подкатегории); без depth — плоский список.
+// tag — учитывать только операции со всеми указанными тегами (параметр повторяется или через запятую).
 func (h *StatisticsHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	if r.Method != http.MethodGet {
-		http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
-		return
-	}
-
-	userID, ok := UserIDFromContext(r.Context())
+	userID, ok := requireUser(w, r)
 	if !ok {
-		http.Error(w, `{"error": "Требуется авторизация"}`, http.StatusUnauthorized)
 		return
 	}
 
@@ -36,7 +33,7 @@ func (h *StatisticsHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	to := r.URL.Query().Get("to")
 
 	if from == "" || to == "" {
-		http.Error(w, `{"error": "Параметры from и to обязательны"}`, http.StatusBadRequest)
+		writeError(w, errPeriodRequired)
 		return
 	}
 
@@ -49,15 +46,32 @@ func (h *StatisticsHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	if aidStr := r.URL.Query().Get("account_id"); aidStr != "" {
 		aid, err := strconv.Atoi(aidStr)
 		if err != nil {
-			http.Error(w, `{"error": "Неверный account_id"}`, http.StatusBadRequest)
+			writeError(w, invalidParam("account_id", "Неверный account_id"))
 			return
 		}
 		accountID = &aid
 	}
 
-	stats, err := h.uc.GetStatistics(userID, from, to, accountID, currency)
+	depth := 0
+	if depthStr := r.URL.Query().Get("depth"); depthStr != "" {
+		d, err := strconv.Atoi(depthStr)
+		if err != nil || d < 0 {
+			writeError(w, invalidParam("depth", "Неверный depth"))
+			return
+		}
+		depth = d
+	}
+
+	stats, err := h.uc.GetStatistics(r.Context(), userID, entity.StatisticsFilter{
+		From:      from,
+		To:        to,
+		AccountID: accountID,
+		Currency:  currency,
+		Depth:     depth,
+		Tags:      parseTags(r.URL.Query()),
+	})
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка получения статистики"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"vue-calc/internal/usecase"
+)
+
+// TagHandler — HTTP-обработчик тегов операций.
+type TagHandler struct {
+	uc *usecase.TagUseCase
+}
+
+// NewTagHandler — конструктор обработчика тегов.
+func NewTagHandler(uc *usecase.TagUseCase) *TagHandler {
+	return &TagHandler{uc: uc}
+}
+
+// Handle — GET /api/tags: теги пользователя и его совместных счетов с числом операций.
+func (h *TagHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	tags, err := h.uc.GetAll(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(tags)
+}
+
+// parseTags — теги из query-параметров: tag можно повторять (?tag=a&tag=b)
+// или перечислять через запятую (?tag=a,b). nil — фильтр по тегам не задан.
+func parseTags(q url.Values) []string {
+	var tags []string
+	for _, v := range q["tag"] {
+		for _, tag := range strings.Split(v, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
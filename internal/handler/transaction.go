@@ -3,8 +3,8 @@ package handler
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
 	"strconv"
-	"strings"
 	"vue-calc/internal/entity"
 	"vue-calc/internal/usecase"
 )
@@ -20,122 +20,152 @@ func NewTransactionHandler(txUC *usecase.TransactionUseCase, accountUC *usecase.
 	return &TransactionHandler{txUC: txUC, accountUC: accountUC}
 }
 
-// Handle — обработка запросов к /api/accounts/{id}/transactions.
-func (h *TransactionHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	userID, ok := UserIDFromContext(r.Context())
+// List — GET /api/accounts/{id}/transactions: страница операций по счёту.
+// Параметры: from, to (YYYY-MM-DD), category_id (число или "uncategorized"),
+// min_amount, max_amount (по модулю), sign (income|expense), comment, tag, sort, limit, cursor.
+func (h *TransactionHandler) List(w http.ResponseWriter, r *http.Request) {
+	accountID, _, ok := accountAccess(w, r, h.accountUC, false)
 	if !ok {
-		http.Error(w, `{"error": "Требуется авторизация"}`, http.StatusUnauthorized)
 		return
 	}
 
-	// Извлекаем account_id из URL: /api/accounts/123/transactions[/456]
-	path := strings.TrimPrefix(r.URL.Path, "/api/accounts/")
-	parts := strings.SplitN(path, "/", 3)
-	if len(parts) < 2 || parts[1] != "transactions" {
-		http.Error(w, `{"error": "Неверный URL"}`, http.StatusBadRequest)
+	filter, err := parseTransactionFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, err)
 		return
 	}
 
-	accountID, err := strconv.Atoi(parts[0])
+	page, err := h.txUC.GetByAccountID(r.Context(), accountID, filter, r.URL.Query().Get("cursor"))
 	if err != nil {
-		http.Error(w, `{"error": "Неверный ID счёта"}`, http.StatusBadRequest)
+		writeError(w, err)
 		return
 	}
+	json.NewEncoder(w).Encode(page)
+}
 
-	// Проверяем, что счёт существует и принадлежит пользователю
-	exists, err := h.accountUC.Exists(accountID, userID)
-	if err != nil {
-		http.Error(w, `{"error": "Ошибка проверки счёта"}`, http.StatusInternalServerError)
-		return
+// parseTransactionFilter — разобрать query-параметры фильтра истории операций.
+func parseTransactionFilter(q url.Values) (entity.TransactionFilter, error) {
+	filter := entity.TransactionFilter{
+		From:    q.Get("from"),
+		To:      q.Get("to"),
+		Sign:    q.Get("sign"),
+		Comment: q.Get("comment"),
+		Tags:    parseTags(q),
+		Sort:    q.Get("sort"),
 	}
-	if !exists {
-		http.Error(w, `{"error": "Счёт не найден"}`, http.StatusNotFound)
-		return
+
+	if err := validateDates(q, "from", "to"); err != nil {
+		return filter, err
 	}
 
-	// DELETE or PUT /api/accounts/{id}/transactions/{txId}
-	if len(parts) == 3 {
-		txID, err := strconv.Atoi(parts[2])
+	if c := q.Get("category_id"); c == "uncategorized" {
+		filter.Uncategorized = true
+	} else if c != "" {
+		id, err := strconv.Atoi(c)
 		if err != nil {
-			http.Error(w, `{"error": "Неверный ID операции"}`, http.StatusBadRequest)
-			return
+			return filter, invalidParam("category_id", "Неверный category_id")
 		}
-		switch r.Method {
-		case http.MethodDelete:
-			h.delete(w, txID, accountID)
-		case http.MethodPut:
-			h.update(w, r, txID, accountID)
-		default:
-			http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
+		filter.CategoryID = &id
+	}
+
+	if v := q.Get("min_amount"); v != "" {
+		amount, err := entity.ParseMoney(v)
+		if err != nil {
+			return filter, invalidParam("min_amount", "Неверный min_amount")
 		}
-		return
+		filter.MinAmount = &amount
+	}
+	if v := q.Get("max_amount"); v != "" {
+		amount, err := entity.ParseMoney(v)
+		if err != nil {
+			return filter, invalidParam("max_amount", "Неверный max_amount")
+		}
+		filter.MaxAmount = &amount
+	}
+
+	if filter.Sign != "" && filter.Sign != "income" && filter.Sign != "expense" {
+		return filter, invalidParam("sign", "Параметр sign должен быть income или expense")
 	}
 
-	switch r.Method {
-	case http.MethodGet:
-		h.getByAccountID(w, accountID)
-	case http.MethodPost:
-		h.create(w, r, accountID)
-	default:
-		http.Error(w, `{"error": "Метод не поддерживается"}`, http.StatusMethodNotAllowed)
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			return filter, invalidParam("limit", "Неверный limit")
+		}
+		filter.Limit = limit
 	}
+
+	return filter, nil
 }
 
-// getByAccountID — получить все транзакции по счёту.
-func (h *TransactionHandler) getByAccountID(w http.ResponseWriter, accountID int) {
-	transactions, err := h.txUC.GetByAccountID(accountID)
+// Create — POST /api/accounts/{id}/transactions: создать операцию по счёту.
+func (h *TransactionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	accountID, userID, ok := accountAccess(w, r, h.accountUC, true)
+	if !ok {
+		return
+	}
+
+	var transaction entity.Transaction
+	if err := decodeJSON(r, &transaction); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	transaction.AccountID = accountID
+	transaction.CreatedBy = &userID
+
+	transaction, err := h.txUC.Create(r.Context(), transaction)
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка получения операций"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
-	json.NewEncoder(w).Encode(transactions)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transaction)
 }
 
-// delete — удалить транзакцию по ID.
-func (h *TransactionHandler) delete(w http.ResponseWriter, txID, accountID int) {
-	err := h.txUC.Delete(txID, accountID)
+// Update — PUT /api/accounts/{id}/transactions/{txId}: изменить операцию.
+func (h *TransactionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	accountID, userID, ok := accountAccess(w, r, h.accountUC, true)
+	if !ok {
+		return
+	}
+	txID, err := pathID(r, "txId", "id", "Неверный ID операции")
 	if err != nil {
-		http.Error(w, `{"error": "Операция не найдена"}`, http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
-}
 
-// update — обновить транзакцию по ID.
-func (h *TransactionHandler) update(w http.ResponseWriter, r *http.Request, txID, accountID int) {
 	var transaction entity.Transaction
-	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
-		http.Error(w, `{"error": "Неверный формат JSON"}`, http.StatusBadRequest)
+	if err := decodeJSON(r, &transaction); err != nil {
+		writeError(w, err)
 		return
 	}
 
-	updated, err := h.txUC.Update(txID, accountID, transaction)
+	updated, err := h.txUC.Update(r.Context(), txID, accountID, userID, transaction)
 	if err != nil {
-		http.Error(w, `{"error": "Операция не найдена"}`, http.StatusNotFound)
+		writeError(w, err)
 		return
 	}
 
 	json.NewEncoder(w).Encode(updated)
 }
 
-// create — создать новую транзакцию по счёту.
-func (h *TransactionHandler) create(w http.ResponseWriter, r *http.Request, accountID int) {
-	var transaction entity.Transaction
-	if err := json.NewDecoder(r.Body).Decode(&transaction); err != nil {
-		http.Error(w, `{"error": "Неверный формат JSON"}`, http.StatusBadRequest)
+// Delete — DELETE /api/accounts/{id}/transactions/{txId}: удалить операцию.
+func (h *TransactionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	accountID, userID, ok := accountAccess(w, r, h.accountUC, true)
+	if !ok {
 		return
 	}
-
-	transaction.AccountID = accountID
-
-	transaction, err := h.txUC.Create(transaction)
+	txID, err := pathID(r, "txId", "id", "Неверный ID операции")
 	if err != nil {
-		http.Error(w, `{"error": "Ошибка создания операции"}`, http.StatusInternalServerError)
+		writeError(w, err)
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(transaction)
+	if err := h.txUC.Delete(r.Context(), txID, accountID, userID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
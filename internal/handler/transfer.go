@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"vue-calc/internal/entity"
+	"vue-calc/internal/usecase"
+)
+
+// TransferHandler — HTTP-обработчик для переводов между счетами.
+type TransferHandler struct {
+	uc *usecase.TransferUseCase
+}
+
+// NewTransferHandler — конструктор обработчика переводов.
+func NewTransferHandler(uc *usecase.TransferUseCase) *TransferHandler {
+	return &TransferHandler{uc: uc}
+}
+
+// Handle — обработка POST /api/transfers.
+func (h *TransferHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	var transfer entity.Transfer
+	if err := decodeJSON(r, &transfer); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	transfer.UserID = userID
+
+	transfer, err := h.uc.Create(r.Context(), transfer)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(transfer)
+}
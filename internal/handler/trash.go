@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"vue-calc/internal/usecase"
+)
+
+// TrashHandler — HTTP-обработчик корзины удалённых объектов.
+// В путях kind — accounts, categories или transactions.
+type TrashHandler struct {
+	uc *usecase.TrashUseCase
+}
+
+// NewTrashHandler — конструктор обработчика корзины.
+func NewTrashHandler(uc *usecase.TrashUseCase) *TrashHandler {
+	return &TrashHandler{uc: uc}
+}
+
+// trashAction — восстановление или окончательное удаление объекта одного типа.
+type trashAction func(ctx context.Context, id, userID int) error
+
+// Get — GET /api/trash: содержимое корзины.
+func (h *TrashHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+
+	trash, err := h.uc.Get(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	json.NewEncoder(w).Encode(trash)
+}
+
+// Restore — POST /api/trash/{kind}/{id}/restore: восстановить объект.
+func (h *TrashHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	h.run(w, r, h.restoreAction(r.PathValue("kind")))
+}
+
+// Purge — DELETE /api/trash/{kind}/{id}: удалить объект окончательно.
+func (h *TrashHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	h.run(w, r, h.purgeAction(r.PathValue("kind")))
+}
+
+// run — выполнить действие над объектом {id}; nil-действие — неизвестный тип объекта.
+func (h *TrashHandler) run(w http.ResponseWriter, r *http.Request, action trashAction) {
+	userID, ok := requireUser(w, r)
+	if !ok {
+		return
+	}
+	if action == nil {
+		writeError(w, errNotFound.WithDetail("неизвестный тип объекта, ожидается accounts, categories или transactions"))
+		return
+	}
+	id, err := pathID(r, "id", "id", "Неверный ID")
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := action(r.Context(), id, userID); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// restoreAction — действие восстановления для типа объекта (nil — неизвестный тип).
+func (h *TrashHandler) restoreAction(kind string) trashAction {
+	switch kind {
+	case "accounts":
+		return h.uc.RestoreAccount
+	case "categories":
+		return h.uc.RestoreCategory
+	case "transactions":
+		return h.uc.RestoreTransaction
+	}
+	return nil
+}
+
+// purgeAction — действие окончательного удаления для типа объекта (nil — неизвестный тип).
+func (h *TrashHandler) purgeAction(kind string) trashAction {
+	switch kind {
+	case "accounts":
+		return h.uc.PurgeAccount
+	case "categories":
+		return h.uc.PurgeCategory
+	case "transactions":
+		return h.uc.PurgeTransaction
+	}
+	return nil
+}
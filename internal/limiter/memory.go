@@ -0,0 +1,101 @@
+// Пакет limiter — хранилище счётчиков попыток в памяти процесса (реализация usecase.AttemptStore).
+// Подходит для одного экземпляра сервера; для нескольких реплик — postgres.AttemptRepo.
+package limiter
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто удалять из памяти забытые и разблокированные ключи.
+const sweepInterval = 10 * time.Minute
+
+// entry — счётчик попыток одного ключа.
+type entry struct {
+	count       int
+	lastAt      time.Time
+	lockedUntil time.Time
+	idle        time.Duration // через сколько без попыток ключ можно забыть
+}
+
+// Memory — счётчики попыток в памяти процесса.
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+// NewMemory — пустое хранилище в памяти.
+func NewMemory() *Memory {
+	return &Memory{entries: map[string]*entry{}}
+}
+
+// LockedUntil — до какого момента ключ заблокирован.
+func (m *Memory) LockedUntil(_ context.Context, key string, now time.Time) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e, ok := m.entries[key]; ok && e.lockedUntil.After(now) {
+		return e.lockedUntil, nil
+	}
+	return time.Time{}, nil
+}
+
+// Incr — увеличить счётчик ключа; счёт начинается заново, если последняя попытка раньше idleSince.
+func (m *Memory) Incr(_ context.Context, key string, now, idleSince time.Time) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep(now)
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{}
+		m.entries[key] = e
+	}
+	if e.lastAt.Before(idleSince) {
+		e.count = 0
+	}
+	e.count++
+	e.lastAt = now
+	e.idle = now.Sub(idleSince)
+	return e.count, nil
+}
+
+// Lock — заблокировать ключ до until, не сокращая уже стоящую блокировку.
+func (m *Memory) Lock(_ context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, ok := m.entries[key]
+	if !ok {
+		e = &entry{}
+		m.entries[key] = e
+	}
+	if until.After(e.lockedUntil) {
+		e.lockedUntil = until
+	}
+	return nil
+}
+
+// Reset — сбросить счётчик и блокировку ключа.
+func (m *Memory) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep — удалить ключи без блокировки, по которым давно не было попыток. Вызывается под m.mu.
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	for key, e := range m.entries {
+		if !e.lockedUntil.After(now) && now.Sub(e.lastAt) > e.idle {
+			delete(m.entries, key)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package mailer
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Log — отправитель для разработки: письмо целиком выводится в лог сервера.
+type Log struct{}
+
+// Send пишет письмо в лог.
+func (m *Log) Send(to, subject, body string) error {
+	if strings.ContainsAny(to+subject, "\r\n") {
+		return ErrInvalidHeader
+	}
+	log.Printf("Письмо для %s: %s\n%s", to, subject, body)
+	return nil
+}
+
+// File — отправитель для разработки: каждое письмо сохраняется в каталог отдельным .eml-файлом,
+// который открывается любым почтовым клиентом.
+type File struct {
+	dir  string
+	from string
+}
+
+// NewFile — отправитель в каталог dir; каталог создаётся, если его нет.
+func NewFile(dir, from string) (*File, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога писем: %w", err)
+	}
+	if from == "" {
+		from = "noreply@localhost"
+	}
+	return &File{dir: dir, from: from}, nil
+}
+
+// Send сохраняет письмо в файл с меткой времени в имени.
+func (m *File) Send(to, subject, body string) error {
+	msg, err := buildMessage(m.from, to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(m.dir, time.Now().Format("20060102-150405")+"-*.eml")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(msg); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	log.Println("Письмо для", to, "сохранено в", filepath.Base(f.Name()))
+	return nil
+}
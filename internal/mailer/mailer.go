@@ -0,0 +1,68 @@
+// Пакет mailer — отправка писем пользователям (реализации usecase.Mailer).
+// SMTP — для продакшена, Log и File — для разработки: письмо попадает в лог или в .eml-файл.
+package mailer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+)
+
+// ErrInvalidHeader — адрес или тема письма содержат перевод строки (попытка подставить заголовки).
+var ErrInvalidHeader = errors.New("недопустимый заголовок письма")
+
+// Options — параметры для создания отправителя по имени.
+type Options struct {
+	From     string // адрес отправителя
+	SMTPAddr string // host:port SMTP-сервера
+	SMTPUser string // логин SMTP; пустой — без авторизации
+	SMTPPass string // пароль SMTP
+	Dir      string // каталог .eml-файлов для отправителя file
+}
+
+// Mailer — отправитель писем.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// New создаёт отправителя по имени: smtp, log или file.
+func New(name string, opts Options) (Mailer, error) {
+	switch strings.TrimSpace(name) {
+	case "smtp":
+		if opts.SMTPAddr == "" || opts.From == "" {
+			return nil, fmt.Errorf("отправитель smtp: не заданы адрес сервера и адрес отправителя")
+		}
+		return &SMTP{Addr: opts.SMTPAddr, Username: opts.SMTPUser, Password: opts.SMTPPass, From: opts.From}, nil
+	case "", "log":
+		return &Log{}, nil
+	case "file":
+		if opts.Dir == "" {
+			return nil, fmt.Errorf("отправитель file: не задан каталог для писем")
+		}
+		return NewFile(opts.Dir, opts.From)
+	default:
+		return nil, fmt.Errorf("неизвестный отправитель писем %q", name)
+	}
+}
+
+// buildMessage — письмо в формате RFC 5322: простой текст в UTF-8, тема в кодировке RFC 2047.
+func buildMessage(from, to, subject, body string) ([]byte, error) {
+	if strings.ContainsAny(from+to+subject, "\r\n") {
+		return nil, ErrInvalidHeader
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes(), nil
+}
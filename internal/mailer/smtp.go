@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"net"
+	"net/smtp"
+)
+
+// SMTP — отправка писем через SMTP-сервер. Если сервер поддерживает STARTTLS,
+// соединение шифруется; авторизация PLAIN — только при заданном логине.
+type SMTP struct {
+	Addr     string // host:port
+	Username string
+	Password string
+	From     string
+}
+
+// Send отправляет письмо одному получателю.
+func (m *SMTP) Send(to, subject, body string) error {
+	msg, err := buildMessage(m.From, to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From, []string{to}, msg)
+}
@@ -0,0 +1,112 @@
+package rateprovider
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"vue-calc/internal/entity"
+)
+
+// cbrURL — ежедневные курсы Центрального банка России (база — RUB).
+const cbrURL = "https://www.cbr.ru/scripts/XML_daily.asp"
+
+// CBR — источник курсов ЦБ РФ в формате XML_daily.asp.
+type CBR struct {
+	URL string
+}
+
+// cbrValCurs — структура XML ЦБ РФ: ValCurs > Valute[CharCode, Nominal, Value].
+// Value — рублей за Nominal единиц валюты, с запятой в качестве разделителя.
+type cbrValCurs struct {
+	Valutes []struct {
+		CharCode string `xml:"CharCode"`
+		Nominal  string `xml:"Nominal"`
+		Value    string `xml:"Value"`
+	} `xml:"Valute"`
+}
+
+// Name — имя источника.
+func (p *CBR) Name() string { return "cbr" }
+
+// FetchRates загружает курсы ЦБ РФ и пересчитывает их от RUB к USD.
+func (p *CBR) FetchRates(ctx context.Context) (*entity.ExchangeRateResponse, error) {
+	resp, err := get(ctx, p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к ЦБ РФ: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ЦБ РФ ответил статусом %d", resp.StatusCode)
+	}
+
+	decoder := xml.NewDecoder(resp.Body)
+	decoder.CharsetReader = charsetReader
+
+	var valCurs cbrValCurs
+	if err := decoder.Decode(&valCurs); err != nil {
+		return nil, fmt.Errorf("ошибка разбора XML ЦБ РФ: %w", err)
+	}
+
+	rubPer := map[string]float64{"RUB": 1}
+	for _, v := range valCurs.Valutes {
+		nominal, err1 := strconv.ParseFloat(strings.TrimSpace(v.Nominal), 64)
+		value, err2 := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(v.Value), ",", "."), 64)
+		if err1 != nil || err2 != nil || nominal <= 0 || value <= 0 {
+			continue
+		}
+		rubPer[v.CharCode] = value / nominal
+	}
+	usd, ok := rubPer["USD"]
+	if !ok {
+		return nil, errors.New("в ответе ЦБ РФ нет курса USD")
+	}
+
+	// Единиц валюты за 1 USD = (рублей за 1 USD) / (рублей за 1 единицу валюты).
+	rates := make(map[string]float64, len(rubPer))
+	for currency, rub := range rubPer {
+		rates[currency] = usd / rub
+	}
+	return success(rates), nil
+}
+
+// charsetReader — ЦБ РФ отдаёт XML в windows-1251, а encoding/xml понимает только UTF-8.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if !strings.EqualFold(charset, "windows-1251") {
+		return nil, fmt.Errorf("неподдерживаемая кодировка %q", charset)
+	}
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(decodeWindows1251(data)), nil
+}
+
+// decodeWindows1251 — перекодировать windows-1251 в UTF-8. Кириллица переводится точно,
+// редкие символы из диапазона 0x80–0xBF (кроме Ё/ё) заменяются на U+FFFD —
+// для курсов нужны только ASCII-поля.
+func decodeWindows1251(data []byte) string {
+	var b strings.Builder
+	b.Grow(len(data) * 2)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c >= 0xC0:
+			b.WriteRune(rune(0x0410 + int(c) - 0xC0))
+		case c == 0xA8:
+			b.WriteRune('Ё')
+		case c == 0xB8:
+			b.WriteRune('ё')
+		default:
+			b.WriteRune(utf8.RuneError)
+		}
+	}
+	return b.String()
+}
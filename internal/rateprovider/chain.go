@@ -0,0 +1,83 @@
+package rateprovider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"vue-calc/internal/entity"
+)
+
+// Chain — источники курсов в порядке приоритета: берётся ответ первого исправного.
+// Для каждого источника запоминается результат последней попытки (см. Health).
+type Chain struct {
+	providers []Provider
+
+	mu     sync.Mutex
+	health []entity.ProviderHealth
+}
+
+// NewChain — конструктор цепочки источников (первый — самый приоритетный).
+func NewChain(providers ...Provider) *Chain {
+	health := make([]entity.ProviderHealth, len(providers))
+	for i, p := range providers {
+		health[i] = entity.ProviderHealth{Name: p.Name(), Priority: i + 1}
+	}
+	return &Chain{providers: providers, health: health}
+}
+
+// FetchRates опрашивает источники по очереди до первого успешного ответа.
+// При отмене ctx опрос прекращается, а источники не отмечаются неисправными.
+func (c *Chain) FetchRates(ctx context.Context) (*entity.ExchangeRateResponse, error) {
+	var errs []error
+	for i, p := range c.providers {
+		resp, err := p.FetchRates(ctx)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err == nil && (resp == nil || len(resp.ConversionRates) == 0) {
+			err = errors.New("пустой ответ")
+		}
+		c.record(i, err)
+		if err != nil {
+			log.Printf("Источник курсов %s недоступен: %v", p.Name(), err)
+			errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+			continue
+		}
+		if i > 0 {
+			log.Printf("Курсы получены из резервного источника %s", p.Name())
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("все источники курсов недоступны: %w", errors.Join(errs...))
+}
+
+// Health — состояние всех источников по последним попыткам.
+func (c *Chain) Health() []entity.ProviderHealth {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]entity.ProviderHealth(nil), c.health...)
+}
+
+// record — запомнить результат попытки источника i.
+func (c *Chain) record(i int, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := &c.health[i]
+	now := time.Now().UTC().Format(time.RFC3339)
+	h.LastCheck = now
+	if err != nil {
+		h.Healthy = false
+		h.LastError = err.Error()
+		h.Failures++
+		return
+	}
+	h.Healthy = true
+	h.LastSuccess = now
+	h.LastError = ""
+	h.Failures = 0
+}
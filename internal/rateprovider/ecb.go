@@ -0,0 +1,70 @@
+package rateprovider
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"vue-calc/internal/entity"
+)
+
+// ecbURL — ежедневные курсы Европейского центрального банка (база — EUR).
+const ecbURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+// ECB — источник курсов ЕЦБ в формате eurofxref-daily.xml.
+type ECB struct {
+	URL string
+}
+
+// ecbEnvelope — структура XML ЕЦБ: Cube > Cube[time] > Cube[currency, rate].
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Name — имя источника.
+func (p *ECB) Name() string { return "ecb" }
+
+// FetchRates загружает курсы ЕЦБ и пересчитывает их от EUR к USD.
+func (p *ECB) FetchRates(ctx context.Context) (*entity.ExchangeRateResponse, error) {
+	resp, err := get(ctx, p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к ЕЦБ: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ЕЦБ ответил статусом %d", resp.StatusCode)
+	}
+
+	var envelope ecbEnvelope
+	if err := xml.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return nil, fmt.Errorf("ошибка разбора XML ЕЦБ: %w", err)
+	}
+
+	// Курсы ЕЦБ — единиц валюты за 1 EUR. Делим на курс USD, чтобы получить единиц за 1 USD.
+	perEUR := map[string]float64{"EUR": 1}
+	for _, r := range envelope.Cube.Cube.Rates {
+		if r.Rate > 0 {
+			perEUR[r.Currency] = r.Rate
+		}
+	}
+	usd, ok := perEUR["USD"]
+	if !ok {
+		return nil, errors.New("в ответе ЕЦБ нет курса USD")
+	}
+
+	rates := make(map[string]float64, len(perEUR))
+	for currency, rate := range perEUR {
+		rates[currency] = rate / usd
+	}
+	return success(rates), nil
+}
@@ -0,0 +1,50 @@
+package rateprovider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"vue-calc/internal/entity"
+)
+
+// exchangeRateAPIURL — базовый URL внешнего API для получения курсов валют.
+const exchangeRateAPIURL = "https://v6.exchangerate-api.com/v6/"
+
+// ExchangeRateAPI — источник курсов exchangerate-api.com (нужен API-ключ).
+type ExchangeRateAPI struct {
+	APIKey string
+}
+
+// Name — имя источника.
+func (p *ExchangeRateAPI) Name() string { return "exchangerate-api" }
+
+// FetchRates делает HTTP-запрос к API и возвращает распарсенный ответ.
+func (p *ExchangeRateAPI) FetchRates(ctx context.Context) (*entity.ExchangeRateResponse, error) {
+	if p.APIKey == "" {
+		return nil, errors.New("EXCHANGE_RATE_API_KEY не задан")
+	}
+
+	resp, err := get(ctx, exchangeRateAPIURL+p.APIKey+"/latest/USD")
+	if err != nil {
+		return nil, fmt.Errorf("ошибка запроса к API курсов: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения ответа API: %w", err)
+	}
+
+	var rateResponse entity.ExchangeRateResponse
+	if err := json.Unmarshal(body, &rateResponse); err != nil {
+		return nil, fmt.Errorf("ошибка разбора JSON от API: %w", err)
+	}
+	if rateResponse.Result != "success" {
+		return nil, fmt.Errorf("API вернул ошибку, result: %s", rateResponse.Result)
+	}
+
+	return &rateResponse, nil
+}
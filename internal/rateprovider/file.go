@@ -0,0 +1,65 @@
+package rateprovider
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"vue-calc/internal/entity"
+)
+
+// File — статический файл курсов для работы без сети.
+// JSON: {"conversion_rates": {"EUR": 0.92, ...}} (как ответ exchangerate-api.com) или просто {"EUR": 0.92, ...}.
+// CSV: строки currency,rate. В обоих случаях rate — единиц валюты за 1 USD.
+type File struct {
+	Path string
+}
+
+// Name — имя источника.
+func (p *File) Name() string { return "file" }
+
+// FetchRates читает курсы из файла; формат определяется по расширению (.json или .csv).
+func (p *File) FetchRates(_ context.Context) (*entity.ExchangeRateResponse, error) {
+	data, err := os.ReadFile(p.Path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла курсов: %w", err)
+	}
+
+	rates := map[string]float64{}
+	switch strings.ToLower(filepath.Ext(p.Path)) {
+	case ".json":
+		var wrapped entity.ExchangeRateResponse
+		if err := json.Unmarshal(data, &wrapped); err == nil && len(wrapped.ConversionRates) > 0 {
+			rates = wrapped.ConversionRates
+		} else if err := json.Unmarshal(data, &rates); err != nil {
+			return nil, fmt.Errorf("ошибка разбора JSON курсов: %w", err)
+		}
+	case ".csv":
+		records, err := csv.NewReader(strings.NewReader(string(data))).ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("ошибка разбора CSV курсов: %w", err)
+		}
+		for _, record := range records {
+			if len(record) < 2 {
+				continue
+			}
+			rate, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+			if err != nil {
+				continue // заголовок или пустая строка
+			}
+			rates[strings.ToUpper(strings.TrimSpace(record[0]))] = rate
+		}
+	default:
+		return nil, fmt.Errorf("неподдерживаемый формат файла курсов %q", p.Path)
+	}
+
+	if len(rates) == 0 {
+		return nil, fmt.Errorf("в файле %s нет курсов", p.Path)
+	}
+	return success(rates), nil
+}
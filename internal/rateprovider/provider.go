@@ -0,0 +1,73 @@
+// Пакет rateprovider — источники курсов валют (реализации usecase.RateFetcher).
+// Все источники приводят курсы к общему виду: сколько единиц валюты дают за 1 USD,
+// как в ответе exchangerate-api.com (entity.ExchangeRateResponse).
+package rateprovider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"vue-calc/internal/entity"
+)
+
+// Provider — один источник курсов.
+type Provider interface {
+	Name() string
+	FetchRates(ctx context.Context) (*entity.ExchangeRateResponse, error)
+}
+
+// Options — параметры для создания источников по именам.
+type Options struct {
+	APIKey   string // ключ exchangerate-api.com
+	FilePath string // путь к файлу курсов для источника file
+}
+
+// httpClient — общий HTTP-клиент с таймаутом, чтобы зависший источник не блокировал обновление.
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// get — GET-запрос к url, прерываемый отменой ctx.
+func get(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return httpClient.Do(req)
+}
+
+// New создаёт источники по списку имён в порядке приоритета.
+// Имена: exchangerate-api, ecb, cbr, file.
+func New(names []string, opts Options) ([]Provider, error) {
+	var providers []Provider
+	for _, name := range names {
+		switch strings.TrimSpace(name) {
+		case "":
+			continue
+		case "exchangerate-api":
+			providers = append(providers, &ExchangeRateAPI{APIKey: opts.APIKey})
+		case "ecb":
+			providers = append(providers, &ECB{URL: ecbURL})
+		case "cbr":
+			providers = append(providers, &CBR{URL: cbrURL})
+		case "file":
+			if opts.FilePath == "" {
+				return nil, fmt.Errorf("источник file: не задан путь к файлу курсов")
+			}
+			providers = append(providers, &File{Path: opts.FilePath})
+		default:
+			return nil, fmt.Errorf("неизвестный источник курсов %q", name)
+		}
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("не задано ни одного источника курсов")
+	}
+	return providers, nil
+}
+
+// success — ответ в общем формате с базовой валютой USD.
+func success(rates map[string]float64) *entity.ExchangeRateResponse {
+	rates["USD"] = 1
+	return &entity.ExchangeRateResponse{Result: "success", ConversionRates: rates}
+}
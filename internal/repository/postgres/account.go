@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"vue-calc/internal/entity"
 )
@@ -16,13 +17,16 @@ func NewAccountRepo(db *sql.DB) *AccountRepo {
 	return &AccountRepo{db: db}
 }
 
-// GetAll — получить все счета пользователя с вычисленными балансами.
-func (r *AccountRepo) GetAll(userID int) ([]entity.Account, error) {
-	rows, err := r.db.Query(`
+// GetAll — получить все счета пользователя с вычисленными балансами:
+// собственные и совместные, к которым ему открыт доступ, с его ролью на каждом.
+func (r *AccountRepo) GetAll(ctx context.Context, userID int) ([]entity.Account, error) {
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT a.id, a.user_id, a.currency, a.comment, a.created_at,
-		       COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.account_id = a.id AND t.deleted_at IS NULL), 0) AS balance
+		       COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.account_id = a.id AND t.deleted_at IS NULL), 0) AS balance,
+		       COALESCE(m.role, 'owner')
 		FROM accounts a
-		WHERE a.user_id = $1 AND a.deleted_at IS NULL
+		LEFT JOIN account_members m ON m.account_id = a.id AND m.user_id = $1
+		WHERE (a.user_id = $1 OR m.user_id IS NOT NULL) AND a.deleted_at IS NULL
 		ORDER BY a.id
 	`, userID)
 	if err != nil {
@@ -33,7 +37,7 @@ func (r *AccountRepo) GetAll(userID int) ([]entity.Account, error) {
 	accounts := []entity.Account{}
 	for rows.Next() {
 		var a entity.Account
-		if err := rows.Scan(&a.ID, &a.UserID, &a.Currency, &a.Comment, &a.CreatedAt, &a.Balance); err != nil {
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Currency, &a.Comment, &a.CreatedAt, &a.Balance, &a.Role); err != nil {
 			return nil, err
 		}
 		accounts = append(accounts, a)
@@ -41,72 +45,151 @@ func (r *AccountRepo) GetAll(userID int) ([]entity.Account, error) {
 	return accounts, nil
 }
 
-// GetByID — получить один счёт по ID (только если принадлежит пользователю).
-func (r *AccountRepo) GetByID(id, userID int) (entity.Account, error) {
+// GetByID — получить один счёт по ID (только если пользователь — владелец или участник).
+func (r *AccountRepo) GetByID(ctx context.Context, id, userID int) (entity.Account, error) {
 	var a entity.Account
-	err := r.db.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT a.id, a.user_id, a.currency, a.comment, a.created_at,
-		       COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.account_id = a.id AND t.deleted_at IS NULL), 0) AS balance
+		       COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.account_id = a.id AND t.deleted_at IS NULL), 0) AS balance,
+		       COALESCE(m.role, 'owner')
 		FROM accounts a
-		WHERE a.id = $1 AND a.user_id = $2 AND a.deleted_at IS NULL
-	`, id, userID).Scan(&a.ID, &a.UserID, &a.Currency, &a.Comment, &a.CreatedAt, &a.Balance)
-	return a, err
+		LEFT JOIN account_members m ON m.account_id = a.id AND m.user_id = $2
+		WHERE a.id = $1 AND (a.user_id = $2 OR m.user_id IS NOT NULL) AND a.deleted_at IS NULL
+	`, id, userID).Scan(&a.ID, &a.UserID, &a.Currency, &a.Comment, &a.CreatedAt, &a.Balance, &a.Role)
+	return a, notFound(err, entity.ErrAccountNotFound)
 }
 
 // Create — создать новый счёт. Возвращает созданный счёт с присвоенным ID.
-func (r *AccountRepo) Create(account entity.Account) (entity.Account, error) {
-	err := r.db.QueryRow(
+func (r *AccountRepo) Create(ctx context.Context, account entity.Account) (entity.Account, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Account{}, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx,
 		"INSERT INTO accounts (currency, comment, user_id) VALUES ($1, $2, $3) RETURNING id, created_at",
 		account.Currency, account.Comment, account.UserID,
 	).Scan(&account.ID, &account.CreatedAt)
-	return account, err
+	if err != nil {
+		return entity.Account{}, err
+	}
+	account.Role = entity.RoleOwner
+	if err := recordAudit(ctx, tx, account.UserID, entity.AuditAccount, account.ID, entity.AuditCreate, nil, account); err != nil {
+		return entity.Account{}, err
+	}
+	return account, tx.Commit()
 }
 
-// Delete — мягко удалить счёт по ID (только если принадлежит пользователю).
-// Также мягко удаляет все транзакции этого счёта.
-func (r *AccountRepo) Delete(id, userID int) (int64, error) {
-	result, err := r.db.Exec(
-		"UPDATE accounts SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
-		id, userID,
-	)
+// Delete — мягко удалить счёт по ID (только владельцем).
+// Также мягко удаляет все транзакции этого счёта, помечая их deleted_with_account,
+// чтобы при восстановлении счёта вернуть только их.
+func (r *AccountRepo) Delete(ctx context.Context, id, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	affected, err := result.RowsAffected()
+	defer tx.Rollback()
+
+	var before entity.Account
+	err = tx.QueryRowContext(ctx, `
+		UPDATE accounts a SET deleted_at = NOW()
+		WHERE a.id = $1 AND a.user_id = $2 AND a.deleted_at IS NULL
+		RETURNING a.id, a.user_id, a.currency, a.comment, a.created_at,
+		          COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.account_id = a.id AND t.deleted_at IS NULL), 0)`,
+		id, userID,
+	).Scan(&before.ID, &before.UserID, &before.Currency, &before.Comment, &before.CreatedAt, &before.Balance)
 	if err != nil {
-		return 0, err
+		return notFound(err, entity.ErrAccountNotFound)
 	}
-	if affected > 0 {
-		_, _ = r.db.Exec(
-			"UPDATE transactions SET deleted_at = NOW() WHERE account_id = $1 AND deleted_at IS NULL",
-			id,
-		)
+	before.Role = entity.RoleOwner
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE transactions SET deleted_at = NOW(), deleted_with_account = TRUE WHERE account_id = $1 AND deleted_at IS NULL",
+		id,
+	); err != nil {
+		return err
 	}
-	return affected, nil
+	if err := recordAudit(ctx, tx, userID, entity.AuditAccount, id, entity.AuditDelete, before, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// UpdateComment — обновить комментарий счёта.
-func (r *AccountRepo) UpdateComment(id, userID int, comment string) error {
-	res, err := r.db.Exec(
-		"UPDATE accounts SET comment = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL",
-		comment, id, userID,
-	)
+// UpdateComment — обновить комментарий счёта (только владельцем).
+func (r *AccountRepo) UpdateComment(ctx context.Context, id, userID int, comment string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rows, err := res.RowsAffected()
+	defer tx.Rollback()
+
+	var before entity.Account
+	err = tx.QueryRowContext(ctx, `
+		SELECT a.id, a.user_id, a.currency, a.comment, a.created_at,
+		       COALESCE((SELECT SUM(t.amount) FROM transactions t WHERE t.account_id = a.id AND t.deleted_at IS NULL), 0)
+		FROM accounts a
+		WHERE a.id = $1 AND a.user_id = $2 AND a.deleted_at IS NULL
+		FOR UPDATE`,
+		id, userID,
+	).Scan(&before.ID, &before.UserID, &before.Currency, &before.Comment, &before.CreatedAt, &before.Balance)
 	if err != nil {
+		return notFound(err, entity.ErrAccountNotFound)
+	}
+	before.Role = entity.RoleOwner
+
+	if _, err := tx.ExecContext(ctx, "UPDATE accounts SET comment = $1 WHERE id = $2", comment, id); err != nil {
 		return err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
+
+	after := before
+	after.Comment = comment
+	if err := recordAudit(ctx, tx, userID, entity.AuditAccount, id, entity.AuditUpdate, before, after); err != nil {
+		return err
 	}
-	return nil
+	return tx.Commit()
+}
+
+// accountAccessible — условие «счёт a доступен пользователю userArg»: он владелец счёта
+// или участник совместного счёта. userArg — плейсхолдер параметра запроса, например "$1".
+func accountAccessible(userArg string) string {
+	return "(a.user_id = " + userArg + " OR EXISTS (SELECT 1 FROM account_members m WHERE m.account_id = a.id AND m.user_id = " + userArg + "))"
 }
 
-// Exists — проверить существование счёта у пользователя.
-func (r *AccountRepo) Exists(id, userID int) (bool, error) {
+// accountEditable — условие «пользователь userArg может вести операции по счёту a»:
+// он владелец счёта или редактор совместного счёта (как canEditAccount в usecase).
+func accountEditable(userArg string) string {
+	return "(a.user_id = " + userArg + " OR EXISTS (SELECT 1 FROM account_members m WHERE m.account_id = a.id AND m.user_id = " + userArg + " AND m.role = '" + entity.RoleEditor + "'))"
+}
+
+// Exists — проверить, что счёт существует и пользователь — его владелец или участник.
+func (r *AccountRepo) Exists(ctx context.Context, id, userID int) (bool, error) {
 	var exists bool
-	err := r.db.QueryRow("SELECT EXISTS(SELECT 1 FROM accounts WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)", id, userID).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM accounts a
+			WHERE a.id = $1 AND a.deleted_at IS NULL AND `+accountAccessible("$2")+`)`,
+		id, userID,
+	).Scan(&exists)
 	return exists, err
 }
+
+// GetRole — роль пользователя на счёте (entity.Role*). entity.ErrAccountNotFound, если доступа к счёту нет.
+func (r *AccountRepo) GetRole(ctx context.Context, id, userID int) (string, error) {
+	var role string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COALESCE(m.role, 'owner')
+		FROM accounts a
+		LEFT JOIN account_members m ON m.account_id = a.id AND m.user_id = $2
+		WHERE a.id = $1 AND (a.user_id = $2 OR m.user_id IS NOT NULL) AND a.deleted_at IS NULL`,
+		id, userID,
+	).Scan(&role)
+	return role, notFound(err, entity.ErrAccountNotFound)
+}
+
+// GetCurrency — получить валюту счёта (для округления сумм до единиц валюты).
+func (r *AccountRepo) GetCurrency(ctx context.Context, id int) (string, error) {
+	var currency string
+	err := r.db.QueryRowContext(ctx, "SELECT currency FROM accounts WHERE id = $1 AND deleted_at IS NULL", id).Scan(&currency)
+	return currency, notFound(err, entity.ErrAccountNotFound)
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+
+	"vue-calc/internal/entity"
+)
+
+// GetMembers — пользователи с доступом к счёту: сначала владелец, затем участники в порядке приглашения.
+func (r *AccountRepo) GetMembers(ctx context.Context, accountID int) ([]entity.AccountMember, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.email, 'owner', a.created_at, 0 AS ord
+		FROM accounts a JOIN users u ON u.id = a.user_id
+		WHERE a.id = $1
+		UNION ALL
+		SELECT u.id, u.email, m.role, m.created_at, 1
+		FROM account_members m JOIN users u ON u.id = m.user_id
+		WHERE m.account_id = $1
+		ORDER BY ord, created_at`,
+		accountID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := []entity.AccountMember{}
+	for rows.Next() {
+		var m entity.AccountMember
+		var ord int
+		if err := rows.Scan(&m.UserID, &m.Email, &m.Role, &m.CreatedAt, &ord); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	return members, rows.Err()
+}
+
+// SetMember — открыть пользователю доступ к счёту с ролью role или сменить роль участника.
+func (r *AccountRepo) SetMember(ctx context.Context, accountID, userID int, role string) (entity.AccountMember, error) {
+	m := entity.AccountMember{UserID: userID, Role: role}
+	err := r.db.QueryRowContext(ctx, `
+		WITH member AS (
+			INSERT INTO account_members (account_id, user_id, role) VALUES ($1, $2, $3)
+			ON CONFLICT (account_id, user_id) DO UPDATE SET role = EXCLUDED.role
+			RETURNING user_id, created_at
+		)
+		SELECT u.email, member.created_at FROM member JOIN users u ON u.id = member.user_id`,
+		accountID, userID, role,
+	).Scan(&m.Email, &m.CreatedAt)
+	return m, err
+}
+
+// RemoveMember — закрыть участнику доступ к счёту. entity.ErrMemberNotFound, если он не участник.
+func (r *AccountRepo) RemoveMember(ctx context.Context, accountID, userID int) error {
+	res, err := r.db.ExecContext(ctx, "DELETE FROM account_members WHERE account_id = $1 AND user_id = $2", accountID, userID)
+	return notFound(requireAffected(res, err), entity.ErrMemberNotFound)
+}
@@ -0,0 +1,122 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"vue-calc/internal/entity"
+)
+
+// attachmentCount — число вложений операции t.
+const attachmentCount = `(SELECT COUNT(*) FROM attachments att WHERE att.transaction_id = t.id)`
+
+// AttachmentRepo — репозиторий вложений операций в PostgreSQL.
+// Все методы работают только с неудалёнными операциями указанного счёта.
+type AttachmentRepo struct {
+	db *sql.DB
+}
+
+// NewAttachmentRepo — конструктор репозитория вложений.
+func NewAttachmentRepo(db *sql.DB) *AttachmentRepo {
+	return &AttachmentRepo{db: db}
+}
+
+// GetByTransaction — вложения операции в порядке загрузки.
+// entity.ErrTransactionNotFound, если операции нет на счёте.
+func (r *AttachmentRepo) GetByTransaction(ctx context.Context, transactionID, accountID int) ([]entity.Attachment, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM transactions WHERE id = $1 AND account_id = $2 AND deleted_at IS NULL)",
+		transactionID, accountID,
+	).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, entity.ErrTransactionNotFound
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, transaction_id, file_name, content_type, size, storage_key, created_at
+		FROM attachments WHERE transaction_id = $1
+		ORDER BY created_at, id`,
+		transactionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attachments := []entity.Attachment{}
+	for rows.Next() {
+		var a entity.Attachment
+		if err := rows.Scan(&a.ID, &a.TransactionID, &a.FileName, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// GetByID — вложение операции по ID.
+func (r *AttachmentRepo) GetByID(ctx context.Context, id, transactionID, accountID int) (entity.Attachment, error) {
+	var a entity.Attachment
+	err := r.db.QueryRowContext(ctx, `
+		SELECT att.id, att.transaction_id, att.file_name, att.content_type, att.size, att.storage_key, att.created_at
+		FROM attachments att
+		JOIN transactions t ON t.id = att.transaction_id
+		WHERE att.id = $1 AND att.transaction_id = $2 AND t.account_id = $3 AND t.deleted_at IS NULL`,
+		id, transactionID, accountID,
+	).Scan(&a.ID, &a.TransactionID, &a.FileName, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt)
+	return a, notFound(err, entity.ErrAttachmentNotFound)
+}
+
+// Create — сохранить запись о вложении. entity.ErrTransactionNotFound, если операции нет на счёте.
+func (r *AttachmentRepo) Create(ctx context.Context, attachment entity.Attachment, accountID int) (entity.Attachment, error) {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO attachments (transaction_id, storage_key, file_name, content_type, size)
+		SELECT t.id, $3, $4, $5, $6 FROM transactions t
+		WHERE t.id = $1 AND t.account_id = $2 AND t.deleted_at IS NULL
+		RETURNING id, created_at`,
+		attachment.TransactionID, accountID, attachment.StorageKey, attachment.FileName, attachment.ContentType, attachment.Size,
+	).Scan(&attachment.ID, &attachment.CreatedAt)
+	if err != nil {
+		return entity.Attachment{}, notFound(err, entity.ErrTransactionNotFound)
+	}
+	return attachment, nil
+}
+
+// Delete — удалить запись о вложении и вернуть её (нужен ключ файла в хранилище).
+func (r *AttachmentRepo) Delete(ctx context.Context, id, transactionID, accountID int) (entity.Attachment, error) {
+	var a entity.Attachment
+	err := r.db.QueryRowContext(ctx, `
+		DELETE FROM attachments att
+		USING transactions t
+		WHERE t.id = att.transaction_id
+		  AND att.id = $1 AND att.transaction_id = $2 AND t.account_id = $3 AND t.deleted_at IS NULL
+		RETURNING att.id, att.transaction_id, att.file_name, att.content_type, att.size, att.storage_key, att.created_at`,
+		id, transactionID, accountID,
+	).Scan(&a.ID, &a.TransactionID, &a.FileName, &a.ContentType, &a.Size, &a.StorageKey, &a.CreatedAt)
+	return a, notFound(err, entity.ErrAttachmentNotFound)
+}
+
+// attachmentKeys — ключи файлов вложений операций, ID которых возвращает подзапрос transactionIDs.
+// Вызывается перед окончательным удалением операций: строки вложений удалятся каскадом,
+// а файлы по этим ключам удаляет юзкейс после фиксации транзакции БД.
+func attachmentKeys(ctx context.Context, q querier, transactionIDs string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, "SELECT storage_key FROM attachments WHERE transaction_id IN ("+transactionIDs+")", args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// AttemptRepo — счётчики попыток входа и регистрации в PostgreSQL (usecase.AttemptStore).
+// Состояние общее для всех экземпляров сервера, работающих с одной БД.
+type AttemptRepo struct {
+	db *sql.DB
+}
+
+// NewAttemptRepo — конструктор репозитория попыток.
+func NewAttemptRepo(db *sql.DB) *AttemptRepo {
+	return &AttemptRepo{db: db}
+}
+
+// LockedUntil — до какого момента ключ заблокирован (нулевое время — не заблокирован).
+func (r *AttemptRepo) LockedUntil(ctx context.Context, key string, now time.Time) (time.Time, error) {
+	var until sql.NullTime
+	err := r.db.QueryRowContext(ctx,
+		"SELECT locked_until FROM auth_attempts WHERE key = $1 AND locked_until > $2",
+		key, now,
+	).Scan(&until)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	return until.Time, err
+}
+
+// Incr — атомарно увеличить счётчик ключа; счёт начинается заново, если последняя попытка раньше idleSince.
+func (r *AttemptRepo) Incr(ctx context.Context, key string, now, idleSince time.Time) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO auth_attempts (key, count, last_at) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN auth_attempts.last_at < $3 THEN 1 ELSE auth_attempts.count + 1 END,
+			last_at = EXCLUDED.last_at
+		RETURNING count`,
+		key, now, idleSince,
+	).Scan(&count)
+	return count, err
+}
+
+// Lock — заблокировать ключ до until, не сокращая уже стоящую блокировку.
+func (r *AttemptRepo) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE auth_attempts SET locked_until = GREATEST(locked_until, $2) WHERE key = $1",
+		key, until,
+	)
+	return err
+}
+
+// Reset — сбросить счётчик и блокировку ключа.
+func (r *AttemptRepo) Reset(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM auth_attempts WHERE key = $1", key)
+	return err
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strconv"
+
+	"vue-calc/internal/entity"
+)
+
+// AuditRepo — чтение журнала изменений. Записи добавляются репозиториями
+// в той же транзакции БД, что и само изменение (см. recordAudit).
+type AuditRepo struct {
+	db *sql.DB
+}
+
+// NewAuditRepo — конструктор репозитория журнала изменений.
+func NewAuditRepo(db *sql.DB) *AuditRepo {
+	return &AuditRepo{db: db}
+}
+
+// GetByUser — записи журнала по фильтру, от новых к старым: изменения самого пользователя
+// и изменения любых участников на доступных ему счетах (см. accountAccessible).
+func (r *AuditRepo) GetByUser(ctx context.Context, userID int, filter entity.AuditFilter) ([]entity.AuditEntry, error) {
+	args := []interface{}{userID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	query := `
+		SELECT id, user_id, account_id, entity_type, entity_id, action, before, after, created_at
+		FROM audit_log
+		WHERE (user_id = $1 OR account_id IN (SELECT a.id FROM accounts a WHERE ` + accountAccessible("$1") + `))`
+
+	if filter.EntityType != "" {
+		query += " AND entity_type = " + arg(filter.EntityType)
+	}
+	if filter.EntityID != nil {
+		query += " AND entity_id = " + arg(*filter.EntityID)
+	}
+	if filter.Action != "" {
+		query += " AND action = " + arg(filter.Action)
+	}
+	if filter.From != "" {
+		query += " AND created_at >= " + arg(filter.From)
+	}
+	if filter.To != "" {
+		query += " AND created_at < (" + arg(filter.To) + "::date + interval '1 day')"
+	}
+	if filter.BeforeID > 0 {
+		query += " AND id < " + arg(filter.BeforeID)
+	}
+	query += " ORDER BY id DESC LIMIT " + arg(filter.Limit)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []entity.AuditEntry{}
+	for rows.Next() {
+		var e entity.AuditEntry
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.UserID, &e.AccountID, &e.EntityType, &e.EntityID, &e.Action, &before, &after, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Before, e.After = before, after
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// recordAudit — добавить запись в журнал изменений через *sql.DB или *sql.Tx.
+// before и after сериализуются в JSON; nil записывается как NULL.
+// Для счетов и операций запоминается счёт (account_id), чтобы запись видели все его участники;
+// поэтому запись об операции добавляется, пока сама операция ещё есть в таблице.
+func recordAudit(ctx context.Context, q querier, userID int, entityType string, entityID int, action string, before, after interface{}) error {
+	beforeJSON, err := auditJSON(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := auditJSON(after)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx,
+		`INSERT INTO audit_log (user_id, account_id, entity_type, entity_id, action, before, after)
+		VALUES ($1, CASE $2::text
+		            WHEN '`+entity.AuditAccount+`' THEN $3::integer
+		            WHEN '`+entity.AuditTransaction+`' THEN (SELECT account_id FROM transactions WHERE id = $3)
+		            END,
+		        $2, $3, $4, $5, $6)`,
+		userID, entityType, entityID, action, beforeJSON, afterJSON,
+	)
+	return err
+}
+
+// auditJSON — значение для колонки JSONB: строка с JSON или NULL.
+func auditJSON(v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
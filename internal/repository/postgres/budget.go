@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"vue-calc/internal/entity"
+)
+
+// BudgetRepo — репозиторий для работы с бюджетами в PostgreSQL.
+type BudgetRepo struct {
+	db *sql.DB
+}
+
+// NewBudgetRepo — конструктор репозитория бюджетов.
+func NewBudgetRepo(db *sql.DB) *BudgetRepo {
+	return &BudgetRepo{db: db}
+}
+
+// GetAll — получить все бюджеты пользователя вместе с названиями категорий.
+func (r *BudgetRepo) GetAll(ctx context.Context, userID int) ([]entity.Budget, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT b.id, b.user_id, b.category_id, c.name, b.amount, b.currency, b.rollover, b.created_at
+		FROM budgets b
+		JOIN categories c ON b.category_id = c.id
+		WHERE b.user_id = $1 AND b.deleted_at IS NULL AND c.deleted_at IS NULL
+		ORDER BY c.name`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	budgets := []entity.Budget{}
+	for rows.Next() {
+		var b entity.Budget
+		if err := rows.Scan(&b.ID, &b.UserID, &b.CategoryID, &b.Category, &b.Amount, &b.Currency, &b.Rollover, &b.CreatedAt); err != nil {
+			return nil, err
+		}
+		budgets = append(budgets, b)
+	}
+	return budgets, rows.Err()
+}
+
+// GetByID — получить бюджет по ID (только если принадлежит пользователю).
+func (r *BudgetRepo) GetByID(ctx context.Context, id, userID int) (entity.Budget, error) {
+	var b entity.Budget
+	err := r.db.QueryRowContext(ctx, `
+		SELECT b.id, b.user_id, b.category_id, c.name, b.amount, b.currency, b.rollover, b.created_at
+		FROM budgets b
+		JOIN categories c ON b.category_id = c.id
+		WHERE b.id = $1 AND b.user_id = $2 AND b.deleted_at IS NULL`,
+		id, userID,
+	).Scan(&b.ID, &b.UserID, &b.CategoryID, &b.Category, &b.Amount, &b.Currency, &b.Rollover, &b.CreatedAt)
+	return b, notFound(err, entity.ErrBudgetNotFound)
+}
+
+// Create — создать бюджет. entity.ErrBudgetExists, если у категории уже есть бюджет
+// (юзкейс проверяет это заранее; сюда попадает параллельное создание).
+func (r *BudgetRepo) Create(ctx context.Context, budget entity.Budget) (entity.Budget, error) {
+	err := r.db.QueryRowContext(ctx,
+		"INSERT INTO budgets (user_id, category_id, amount, currency, rollover) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+		budget.UserID, budget.CategoryID, budget.Amount, budget.Currency, budget.Rollover,
+	).Scan(&budget.ID, &budget.CreatedAt)
+	if isUniqueViolation(err) {
+		return entity.Budget{}, entity.ErrBudgetExists
+	}
+	return budget, err
+}
+
+// Update — изменить лимит, валюту и перенос остатка. Категория бюджета не меняется.
+func (r *BudgetRepo) Update(ctx context.Context, budget entity.Budget) (entity.Budget, error) {
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE budgets SET amount = $1, currency = $2, rollover = $3
+		WHERE id = $4 AND user_id = $5 AND deleted_at IS NULL
+		RETURNING category_id, created_at`,
+		budget.Amount, budget.Currency, budget.Rollover, budget.ID, budget.UserID,
+	).Scan(&budget.CategoryID, &budget.CreatedAt)
+	return budget, notFound(err, entity.ErrBudgetNotFound)
+}
+
+// Delete — мягко удалить бюджет.
+func (r *BudgetRepo) Delete(ctx context.Context, id, userID int) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE budgets SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
+		id, userID,
+	)
+	return notFound(requireAffected(res, err), entity.ErrBudgetNotFound)
+}
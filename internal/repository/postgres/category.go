@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 	"vue-calc/internal/entity"
 )
@@ -16,9 +17,14 @@ func NewCategoryRepo(db *sql.DB) *CategoryRepo {
 }
 
 // GetAllByUserID — получить все категории пользователя.
-func (r *CategoryRepo) GetAllByUserID(userID int) ([]entity.Category, error) {
-	rows, err := r.db.Query(
-		"SELECT id, user_id, name, created_at FROM categories WHERE user_id = $1 AND deleted_at IS NULL ORDER BY name",
+func (r *CategoryRepo) GetAllByUserID(ctx context.Context, userID int) ([]entity.Category, error) {
+	// Подкатегория удалённого родителя показывается как корневая.
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.id, c.user_id, c.name, p.id, c.created_at
+		FROM categories c
+		LEFT JOIN categories p ON p.id = c.parent_id AND p.deleted_at IS NULL
+		WHERE c.user_id = $1 AND c.deleted_at IS NULL
+		ORDER BY c.name`,
 		userID,
 	)
 	if err != nil {
@@ -29,7 +35,7 @@ func (r *CategoryRepo) GetAllByUserID(userID int) ([]entity.Category, error) {
 	categories := []entity.Category{}
 	for rows.Next() {
 		var c entity.Category
-		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.CreatedAt); err != nil {
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.ParentID, &c.CreatedAt); err != nil {
 			return nil, err
 		}
 		categories = append(categories, c)
@@ -37,30 +43,194 @@ func (r *CategoryRepo) GetAllByUserID(userID int) ([]entity.Category, error) {
 	return categories, nil
 }
 
-// Create — создать новую категорию.
-func (r *CategoryRepo) Create(category entity.Category) (entity.Category, error) {
-	err := r.db.QueryRow(
-		"INSERT INTO categories (user_id, name) VALUES ($1, $2) RETURNING id, created_at",
-		category.UserID, category.Name,
+// Create — создать новую категорию. entity.ErrCategoryExists, если название уже занято.
+func (r *CategoryRepo) Create(ctx context.Context, category entity.Category) (entity.Category, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Category{}, err
+	}
+	defer tx.Rollback()
+
+	category, err = createCategory(ctx, tx, category)
+	if err != nil {
+		return entity.Category{}, err
+	}
+	return category, tx.Commit()
+}
+
+// createCategory — вставка категории с записью в журнал изменений; q должен быть транзакцией БД.
+// Юзкейс проверяет уникальность названия заранее; сюда дубликат попадает только
+// при параллельном создании — entity.ErrCategoryExists.
+func createCategory(ctx context.Context, q querier, category entity.Category) (entity.Category, error) {
+	err := q.QueryRowContext(ctx,
+		"INSERT INTO categories (user_id, name, parent_id) VALUES ($1, $2, $3) RETURNING id, created_at",
+		category.UserID, category.Name, category.ParentID,
 	).Scan(&category.ID, &category.CreatedAt)
-	return category, err
+	if isUniqueViolation(err) {
+		return entity.Category{}, entity.ErrCategoryExists
+	}
+	if err != nil {
+		return entity.Category{}, err
+	}
+	if err := recordAudit(ctx, q, category.UserID, entity.AuditCategory, category.ID, entity.AuditCreate, nil, category); err != nil {
+		return entity.Category{}, err
+	}
+	return category, nil
 }
 
 // Delete — мягко удалить категорию по ID (только если принадлежит пользователю).
-func (r *CategoryRepo) Delete(id, userID int) error {
-	res, err := r.db.Exec(
-		"UPDATE categories SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
-		id, userID,
-	)
+func (r *CategoryRepo) Delete(ctx context.Context, id, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
-	rows, err := res.RowsAffected()
+	defer tx.Rollback()
+
+	var before entity.Category
+	err = tx.QueryRowContext(ctx,
+		"UPDATE categories SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL RETURNING id, user_id, name, parent_id, created_at",
+		id, userID,
+	).Scan(&before.ID, &before.UserID, &before.Name, &before.ParentID, &before.CreatedAt)
 	if err != nil {
+		return notFound(err, entity.ErrCategoryNotFound)
+	}
+	if err := recordAudit(ctx, tx, userID, entity.AuditCategory, id, entity.AuditDelete, before, nil); err != nil {
 		return err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
+	return tx.Commit()
+}
+
+// Update — изменить название и родителя категории. Проверку на циклы и дубликаты выполняет юзкейс;
+// дубликат, созданный параллельно, — entity.ErrCategoryExists.
+func (r *CategoryRepo) Update(ctx context.Context, category entity.Category) (entity.Category, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Category{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := getCategoryForUpdate(ctx, tx, category.ID, category.UserID)
+	if err != nil {
+		return entity.Category{}, err
 	}
-	return nil
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE categories SET name = $1, parent_id = $2 WHERE id = $3",
+		category.Name, category.ParentID, category.ID,
+	)
+	if isUniqueViolation(err) {
+		return entity.Category{}, entity.ErrCategoryExists
+	}
+	if err != nil {
+		return entity.Category{}, err
+	}
+
+	after := before
+	after.Name = category.Name
+	after.ParentID = category.ParentID
+	if err := recordAudit(ctx, tx, category.UserID, entity.AuditCategory, category.ID, entity.AuditUpdate, before, after); err != nil {
+		return entity.Category{}, err
+	}
+	return after, tx.Commit()
+}
+
+// Merge — перенести в категорию targetID все операции, части разбитых операций, повторяющиеся
+// правила и подкатегории категории sourceID, затем мягко удалить sourceID. Бюджет источника переходит
+// к цели, если у цели своего бюджета нет, иначе удаляется. Возвращает число перенесённых операций.
+func (r *CategoryRepo) Merge(ctx context.Context, sourceID, targetID, userID int) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	source, err := getCategoryForUpdate(ctx, tx, sourceID, userID)
+	if err != nil {
+		return 0, err
+	}
+	target, err := getCategoryForUpdate(ctx, tx, targetID, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	// Переносим и удалённые операции, чтобы после восстановления из корзины они попали в цель.
+	// Части разбитых операций тоже переносятся; операция считается один раз,
+	// даже если в исходной категории были и она сама, и несколько её частей.
+	rows, err := tx.QueryContext(ctx, `
+		WITH moved AS (UPDATE transactions SET category_id = $1 WHERE category_id = $2 RETURNING id),
+		     moved_splits AS (UPDATE transaction_splits SET category_id = $1 WHERE category_id = $2 RETURNING transaction_id)
+		SELECT id FROM moved UNION SELECT transaction_id FROM moved_splits
+		ORDER BY 1`,
+		targetID, sourceID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	movedIDs := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		movedIDs = append(movedIDs, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	statements := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"UPDATE recurring_rules SET category_id = $1 WHERE category_id = $2", []interface{}{targetID, sourceID}},
+		{`UPDATE budgets SET category_id = $1
+		  WHERE category_id = $2 AND deleted_at IS NULL
+		    AND NOT EXISTS (SELECT 1 FROM budgets WHERE category_id = $1 AND deleted_at IS NULL)`, []interface{}{targetID, sourceID}},
+		{"UPDATE budgets SET deleted_at = NOW() WHERE category_id = $1 AND deleted_at IS NULL", []interface{}{sourceID}},
+		{"UPDATE categories SET parent_id = $1 WHERE parent_id = $2", []interface{}{targetID, sourceID}},
+		{"UPDATE categories SET deleted_at = NOW() WHERE id = $1", []interface{}{sourceID}},
+	}
+	for _, st := range statements {
+		if _, err := tx.ExecContext(ctx, st.query, st.args...); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := recordAudit(ctx, tx, userID, entity.AuditCategory, sourceID, entity.AuditMerge, source, target); err != nil {
+		return 0, err
+	}
+	// У каждой перенесённой операции — своя запись об изменении категории (её или её частей),
+	// чтобы перенос был виден в истории операции и участникам совместных счетов.
+	for _, id := range movedIDs {
+		before := mergedCategory{CategoryID: sourceID}
+		after := mergedCategory{CategoryID: targetID}
+		if err := recordAudit(ctx, tx, userID, entity.AuditTransaction, id, entity.AuditUpdate, before, after); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(movedIDs)), tx.Commit()
+}
+
+// mergedCategory — состояние операции в журнале при слиянии категорий: меняется только категория.
+type mergedCategory struct {
+	CategoryID int `json:"category_id"`
+}
+
+// getCategoryForUpdate — прочитать категорию пользователя и заблокировать её строку до конца транзакции БД.
+func getCategoryForUpdate(ctx context.Context, q querier, id, userID int) (entity.Category, error) {
+	var c entity.Category
+	err := q.QueryRowContext(ctx,
+		"SELECT id, user_id, name, parent_id, created_at FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE",
+		id, userID,
+	).Scan(&c.ID, &c.UserID, &c.Name, &c.ParentID, &c.CreatedAt)
+	return c, notFound(err, entity.ErrCategoryNotFound)
+}
+
+// Exists — проверить существование категории у пользователя.
+func (r *CategoryRepo) Exists(ctx context.Context, id, userID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)", id, userID).Scan(&exists)
+	return exists, err
 }
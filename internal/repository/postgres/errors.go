@@ -0,0 +1,28 @@
+package postgres
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"vue-calc/internal/entity"
+)
+
+// errNoAuthor — операция создаётся без автора: в журнал изменений её не записать.
+var errNoAuthor = errors.New("не указан автор операции (CreatedBy)")
+
+// notFound — заменить sql.ErrNoRows доменной ошибкой «не найдено» target;
+// остальные ошибки возвращаются как есть.
+func notFound(err error, target *entity.Error) error {
+	if err == sql.ErrNoRows {
+		return target
+	}
+	return err
+}
+
+// isUniqueViolation — нарушено ограничение уникальности (код PostgreSQL 23505).
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
 
 	"vue-calc/internal/entity"
@@ -17,8 +18,8 @@ func NewRateRepo(db *sql.DB) *RateRepo {
 }
 
 // GetAll возвращает все курсы валют из таблицы rates.
-func (r *RateRepo) GetAll() ([]entity.Rate, error) {
-	rows, err := r.db.Query("SELECT id, currency, rate_to_usd, updated_at FROM rates")
+func (r *RateRepo) GetAll(ctx context.Context) ([]entity.Rate, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, currency, rate_to_usd, updated_at FROM rates")
 	if err != nil {
 		return nil, err
 	}
@@ -37,12 +38,77 @@ func (r *RateRepo) GetAll() ([]entity.Rate, error) {
 
 // Upsert вставляет или обновляет курс валюты (INSERT ... ON CONFLICT DO UPDATE).
 // Если валюта уже есть — обновляет курс и время.
-func (r *RateRepo) Upsert(currency string, rateToUSD float64) error {
-	_, err := r.db.Exec(`
+// Тот же курс записывается в историю на сегодняшнюю дату (последнее обновление за день побеждает).
+func (r *RateRepo) Upsert(ctx context.Context, currency string, rateToUSD float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
 		INSERT INTO rates (currency, rate_to_usd, updated_at)
 		VALUES ($1, $2, CURRENT_TIMESTAMP)
 		ON CONFLICT (currency)
 		DO UPDATE SET rate_to_usd = $2, updated_at = CURRENT_TIMESTAMP`,
-		currency, rateToUSD)
+		currency, rateToUSD); err != nil {
+		return err
+	}
+
+	if err := upsertHistory(ctx, tx, currency, "", rateToUSD); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpsertHistory записывает курс валюты на прошедшую дату (для загрузки истории).
+func (r *RateRepo) UpsertHistory(ctx context.Context, currency, date string, rateToUSD float64) error {
+	return upsertHistory(ctx, r.db, currency, date, rateToUSD)
+}
+
+// GetHistory возвращает историю курсов за период. Пустые параметры не ограничивают выборку.
+func (r *RateRepo) GetHistory(ctx context.Context, currency, from, to string) ([]entity.RateHistory, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT currency, date::text, rate_to_usd
+		FROM rate_history
+		WHERE ($1 = '' OR currency = $1)
+		  AND ($2 = '' OR date >= $2::date)
+		  AND ($3 = '' OR date <= $3::date)
+		ORDER BY currency, date`,
+		currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := []entity.RateHistory{}
+	for rows.Next() {
+		var h entity.RateHistory
+		if err := rows.Scan(&h.Currency, &h.Date, &h.RateToUSD); err != nil {
+			return nil, err
+		}
+		history = append(history, h)
+	}
+	return history, rows.Err()
+}
+
+// upsertHistory — записать курс в историю; пустая date — сегодняшняя дата.
+func upsertHistory(ctx context.Context, q querier, currency, date string, rateToUSD float64) error {
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO rate_history (currency, date, rate_to_usd)
+		VALUES ($1, COALESCE(NULLIF($2, '')::date, CURRENT_DATE), $3)
+		ON CONFLICT (currency, date)
+		DO UPDATE SET rate_to_usd = $3`,
+		currency, date, rateToUSD)
 	return err
 }
+
+// GetByCurrency возвращает курс одной валюты. Если валюты нет — entity.ErrRateNotFound.
+func (r *RateRepo) GetByCurrency(ctx context.Context, currency string) (entity.Rate, error) {
+	var rate entity.Rate
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, currency, rate_to_usd, updated_at FROM rates WHERE currency = $1",
+		currency,
+	).Scan(&rate.ID, &rate.Currency, &rate.RateToUSD, &rate.UpdatedAt)
+	return rate, notFound(err, entity.ErrRateNotFound)
+}
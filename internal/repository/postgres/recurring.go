@@ -0,0 +1,203 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"vue-calc/internal/entity"
+)
+
+// RecurringRepo — репозиторий правил повторяющихся операций в PostgreSQL.
+type RecurringRepo struct {
+	db *sql.DB
+}
+
+// NewRecurringRepo — конструктор репозитория повторяющихся операций.
+func NewRecurringRepo(db *sql.DB) *RecurringRepo {
+	return &RecurringRepo{db: db}
+}
+
+const recurringColumns = `id, user_id, account_id, amount, category_id, comment, frequency, day_of_month,
+	start_date::text, end_date::text, next_date::text, paused, created_at`
+
+func scanRecurringRule(row interface{ Scan(...interface{}) error }) (entity.RecurringRule, error) {
+	var rule entity.RecurringRule
+	err := row.Scan(&rule.ID, &rule.UserID, &rule.AccountID, &rule.Amount, &rule.CategoryID, &rule.Comment,
+		&rule.Frequency, &rule.DayOfMonth, &rule.StartDate, &rule.EndDate, &rule.NextDate, &rule.Paused, &rule.CreatedAt)
+	return rule, err
+}
+
+// GetAll — получить все правила пользователя.
+func (r *RecurringRepo) GetAll(ctx context.Context, userID int) ([]entity.RecurringRule, error) {
+	rows, err := r.db.QueryContext(ctx,
+		"SELECT "+recurringColumns+" FROM recurring_rules WHERE user_id = $1 AND deleted_at IS NULL ORDER BY id",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []entity.RecurringRule{}
+	for rows.Next() {
+		rule, err := scanRecurringRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// GetByID — получить правило по ID (только если принадлежит пользователю).
+func (r *RecurringRepo) GetByID(ctx context.Context, id, userID int) (entity.RecurringRule, error) {
+	rule, err := scanRecurringRule(r.db.QueryRowContext(ctx,
+		"SELECT "+recurringColumns+" FROM recurring_rules WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
+		id, userID,
+	))
+	return rule, notFound(err, entity.ErrRecurringNotFound)
+}
+
+// Create — создать правило. Возвращает правило с присвоенным ID.
+func (r *RecurringRepo) Create(ctx context.Context, rule entity.RecurringRule) (entity.RecurringRule, error) {
+	return scanRecurringRule(r.db.QueryRowContext(ctx, `
+		INSERT INTO recurring_rules (user_id, account_id, amount, category_id, comment, frequency, day_of_month, start_date, end_date, next_date, paused)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING `+recurringColumns,
+		rule.UserID, rule.AccountID, rule.Amount, rule.CategoryID, rule.Comment, rule.Frequency, rule.DayOfMonth,
+		rule.StartDate, rule.EndDate, rule.NextDate, rule.Paused,
+	))
+}
+
+// Update — обновить правило целиком (по ID и user_id).
+func (r *RecurringRepo) Update(ctx context.Context, rule entity.RecurringRule) (entity.RecurringRule, error) {
+	rule, err := scanRecurringRule(r.db.QueryRowContext(ctx, `
+		UPDATE recurring_rules
+		SET account_id = $1, amount = $2, category_id = $3, comment = $4, frequency = $5, day_of_month = $6,
+		    start_date = $7, end_date = $8, next_date = $9, paused = $10
+		WHERE id = $11 AND user_id = $12 AND deleted_at IS NULL
+		RETURNING `+recurringColumns,
+		rule.AccountID, rule.Amount, rule.CategoryID, rule.Comment, rule.Frequency, rule.DayOfMonth,
+		rule.StartDate, rule.EndDate, rule.NextDate, rule.Paused,
+		rule.ID, rule.UserID,
+	))
+	return rule, notFound(err, entity.ErrRecurringNotFound)
+}
+
+// Delete — мягко удалить правило. Уже созданные по нему транзакции остаются.
+func (r *RecurringRepo) Delete(ctx context.Context, id, userID int) error {
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE recurring_rules SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL",
+		id, userID,
+	)
+	return notFound(requireAffected(res, err), entity.ErrRecurringNotFound)
+}
+
+// Skip — отметить срабатывание правила в дату date как пропущенное.
+// Повторный пропуск той же даты ничего не меняет.
+func (r *RecurringRepo) Skip(ctx context.Context, id int, date string) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO recurring_occurrences (rule_id, date, skipped) VALUES ($1, $2, TRUE) ON CONFLICT DO NOTHING",
+		id, date,
+	)
+	return err
+}
+
+// GetDue — правила, у которых наступила дата срабатывания (next_date <= date).
+// Приостановленные, удалённые и закончившиеся правила, а также правила удалённых счетов
+// и совместных счетов, на которых автор правила больше не редактор, не возвращаются.
+func (r *RecurringRepo) GetDue(ctx context.Context, date string) ([]entity.RecurringRule, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+recurringColumns+`
+		FROM recurring_rules
+		WHERE deleted_at IS NULL
+		  AND NOT paused
+		  AND next_date <= $1
+		  AND (end_date IS NULL OR next_date <= end_date)
+		  AND account_id IN (
+		    SELECT a.id FROM accounts a
+		    WHERE a.deleted_at IS NULL
+		      AND (a.user_id = recurring_rules.user_id OR EXISTS (
+		        SELECT 1 FROM account_members m
+		        WHERE m.account_id = a.id AND m.user_id = recurring_rules.user_id AND m.role = 'editor')))
+		ORDER BY id`,
+		date,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []entity.RecurringRule{}
+	for rows.Next() {
+		rule, err := scanRecurringRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// Materialize — обработать срабатывание rule.NextDate и сдвинуть next_date на nextDate.
+// Всё выполняется в одной транзакции БД:
+//   - next_date сдвигается, только если ещё равен rule.NextDate — так два воркера
+//     (или повторный запуск после сбоя) не обработают одну дату дважды;
+//   - запись в recurring_occurrences защищена первичным ключом: если дата уже
+//     пропущена пользователем или обработана, транзакция не создаётся.
+//
+// Возвращает true, если транзакция была создана.
+func (r *RecurringRepo) Materialize(ctx context.Context, rule entity.RecurringRule, nextDate string) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE recurring_rules SET next_date = $1 WHERE id = $2 AND next_date = $3",
+		nextDate, rule.ID, rule.NextDate,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	res, err = tx.ExecContext(ctx,
+		"INSERT INTO recurring_occurrences (rule_id, date) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		rule.ID, rule.NextDate,
+	)
+	if err != nil {
+		return false, err
+	}
+	inserted, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	if inserted > 0 {
+		created, err := createTransaction(ctx, tx, entity.Transaction{
+			AccountID:  rule.AccountID,
+			Amount:     rule.Amount,
+			Comment:    rule.Comment,
+			CategoryID: rule.CategoryID,
+			CreatedAt:  rule.NextDate,
+			CreatedBy:  &rule.UserID,
+		})
+		if err != nil {
+			return false, err
+		}
+		if _, err := tx.ExecContext(ctx,
+			"UPDATE recurring_occurrences SET transaction_id = $1 WHERE rule_id = $2 AND date = $3",
+			created.ID, rule.ID, rule.NextDate,
+		); err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return inserted > 0, nil
+}
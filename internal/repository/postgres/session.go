@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"vue-calc/internal/entity"
+)
+
+// SessionRepo — репозиторий сессий и refresh-токенов в PostgreSQL.
+type SessionRepo struct {
+	db *sql.DB
+}
+
+// NewSessionRepo — конструктор репозитория сессий.
+func NewSessionRepo(db *sql.DB) *SessionRepo {
+	return &SessionRepo{db: db}
+}
+
+// Create — создать сессию вместе с первым refresh-токеном.
+func (r *SessionRepo) Create(ctx context.Context, sessionID string, userID int, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO sessions (id, user_id) VALUES ($1, $2)", sessionID, userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (token_hash, session_id, expires_at) VALUES ($1, $2, $3)",
+		tokenHash, sessionID, expiresAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetRefreshToken — найти refresh-токен по хэшу вместе с состоянием его сессии.
+func (r *SessionRepo) GetRefreshToken(ctx context.Context, tokenHash string) (entity.RefreshToken, error) {
+	var t entity.RefreshToken
+	err := r.db.QueryRowContext(ctx, `
+		SELECT rt.token_hash, rt.session_id, s.user_id, rt.expires_at,
+		       rt.used_at IS NOT NULL, s.revoked_at IS NOT NULL
+		FROM refresh_tokens rt
+		JOIN sessions s ON rt.session_id = s.id
+		WHERE rt.token_hash = $1`,
+		tokenHash,
+	).Scan(&t.Hash, &t.SessionID, &t.UserID, &t.ExpiresAt, &t.Used, &t.Revoked)
+	return t, notFound(err, entity.ErrRefreshTokenNotFound)
+}
+
+// Rotate — пометить токен использованным и выдать вместо него новый в той же сессии.
+// Возвращает false, если токен уже был использован (в том числе параллельным запросом).
+func (r *SessionRepo) Rotate(ctx context.Context, oldHash, newHash, sessionID string, expiresAt time.Time) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1 AND used_at IS NULL",
+		oldHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO refresh_tokens (token_hash, session_id, expires_at) VALUES ($1, $2, $3)",
+		newHash, sessionID, expiresAt,
+	); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Revoke — отозвать сессию: все её refresh- и access-токены перестают действовать.
+func (r *SessionRepo) Revoke(ctx context.Context, sessionID string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL",
+		sessionID,
+	)
+	return err
+}
+
+// IsActive — сессия существует, принадлежит пользователю и не отозвана.
+func (r *SessionRepo) IsActive(ctx context.Context, sessionID string, userID int) (bool, error) {
+	var active bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS(SELECT 1 FROM sessions WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL)",
+		sessionID, userID,
+	).Scan(&active)
+	return active, err
+}
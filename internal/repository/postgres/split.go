@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"github.com/lib/pq"
+
+	"vue-calc/internal/entity"
+)
+
+// splitLines — строки операции t для статистики по категориям: её части,
+// а если разбивки нет — сама операция целиком. Колонки: l.amount, l.category_id.
+const splitLines = `
+		JOIN LATERAL (
+			SELECT s.amount, s.category_id FROM transaction_splits s WHERE s.transaction_id = t.id
+			UNION ALL
+			SELECT t.amount, t.category_id
+			WHERE NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id)
+		) l ON TRUE`
+
+// categoryCondition — условие фильтра операций t по категории с учётом разбивки:
+// операция подходит, если категория стоит на ней самой или на любой её части.
+// Без категории — операция без разбивки и без категории или с частью без категории.
+func categoryCondition(uncategorized bool, categoryID *int, arg func(interface{}) string) string {
+	if uncategorized {
+		return ` AND ((t.category_id IS NULL AND NOT EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id))
+		      OR EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id AND s.category_id IS NULL))`
+	}
+	if categoryID != nil {
+		p := arg(*categoryID)
+		return ` AND (t.category_id = ` + p + `
+		      OR EXISTS (SELECT 1 FROM transaction_splits s WHERE s.transaction_id = t.id AND s.category_id = ` + p + `))`
+	}
+	return ""
+}
+
+// getSplits — разбивка операций с указанными ID. У каждой операции из ids в результате
+// есть запись (пустой список, если операция не разбита).
+func getSplits(ctx context.Context, q querier, ids []int) (map[int][]entity.TransactionSplit, error) {
+	result := make(map[int][]entity.TransactionSplit, len(ids))
+	for _, id := range ids {
+		result[id] = []entity.TransactionSplit{}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT s.transaction_id, s.id, s.amount, s.category_id, COALESCE(c.name, ''), s.comment
+		FROM transaction_splits s
+		LEFT JOIN categories c ON c.id = s.category_id
+		WHERE s.transaction_id = ANY($1)
+		ORDER BY s.transaction_id, s.id`,
+		pq.Array(ids),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var transactionID int
+		var s entity.TransactionSplit
+		if err := rows.Scan(&transactionID, &s.ID, &s.Amount, &s.CategoryID, &s.Category, &s.Comment); err != nil {
+			return nil, err
+		}
+		result[transactionID] = append(result[transactionID], s)
+	}
+	return result, rows.Err()
+}
+
+// setTransactionSplits — заменить разбивку операции на splits и вернуть сохранённые части.
+func setTransactionSplits(ctx context.Context, q querier, transactionID int, splits []entity.TransactionSplit) ([]entity.TransactionSplit, error) {
+	if _, err := q.ExecContext(ctx, "DELETE FROM transaction_splits WHERE transaction_id = $1", transactionID); err != nil {
+		return nil, err
+	}
+
+	saved := make([]entity.TransactionSplit, 0, len(splits))
+	for _, s := range splits {
+		err := q.QueryRowContext(ctx, `
+			INSERT INTO transaction_splits (transaction_id, amount, category_id, comment)
+			VALUES ($1, $2, $3, $4)
+			RETURNING id, COALESCE((SELECT name FROM categories WHERE id = $3), '')`,
+			transactionID, s.Amount, s.CategoryID, s.Comment,
+		).Scan(&s.ID, &s.Category)
+		if err != nil {
+			return nil, err
+		}
+		saved = append(saved, s)
+	}
+	return saved, nil
+}
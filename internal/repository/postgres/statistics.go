@@ -1,10 +1,26 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"sort"
+	"strconv"
+
+	"github.com/lib/pq"
+
 	"vue-calc/internal/entity"
 )
 
+// rateOnDate — коэффициент пересчёта суммы транзакции из валюты счёта в целевую ($2)
+// по курсам, действовавшим на дату транзакции (последняя запись rate_history не позже этой даты).
+// Если история на ту дату пуста — берётся текущий курс из rates.
+const rateOnDate = `(COALESCE(
+			(SELECT h.rate_to_usd FROM rate_history h WHERE h.currency = a.currency AND h.date <= t.created_at::date ORDER BY h.date DESC LIMIT 1),
+			r_src.rate_to_usd)
+		/ COALESCE(
+			(SELECT h.rate_to_usd FROM rate_history h WHERE h.currency = $2 AND h.date <= t.created_at::date ORDER BY h.date DESC LIMIT 1),
+			r_tgt.rate_to_usd))::numeric`
+
 // StatisticsRepo — репозиторий для агрегации статистики по транзакциям.
 type StatisticsRepo struct {
 	db *sql.DB
@@ -16,28 +32,41 @@ func NewStatisticsRepo(db *sql.DB) *StatisticsRepo {
 }
 
 // GetStatistics — получить агрегированную статистику за период.
-// Все суммы пересчитываются в targetCurrency через таблицу rates.
-func (r *StatisticsRepo) GetStatistics(userID int, from, to string, accountID *int, targetCurrency string) (entity.StatisticsResponse, error) {
-	result := entity.StatisticsResponse{Currency: targetCurrency}
+// Все суммы пересчитываются в filter.Currency по курсу на дату каждой транзакции (см. rateOnDate).
+// Переводы между счетами (transfer_id IS NOT NULL) не считаются ни доходом, ни расходом.
+// Коэффициент приводится к NUMERIC, поэтому суммы считаются точно; до единиц валюты их округляет юзкейс.
+// filter.Depth > 0 — статистика по категориям возвращается деревом глубиной Depth (см. rollUpCategories).
+func (r *StatisticsRepo) GetStatistics(ctx context.Context, userID int, filter entity.StatisticsFilter) (entity.StatisticsResponse, error) {
+	result := entity.StatisticsResponse{Currency: filter.Currency}
 
-	totals, err := r.getTotals(userID, from, to, accountID, targetCurrency)
+	totals, err := r.getTotals(ctx, userID, filter)
 	if err != nil {
 		return result, err
 	}
 	result.TotalIncome = totals.income
 	result.TotalExpense = totals.expense
 
-	result.IncomeByCategory, err = r.getCategoryStats(userID, from, to, accountID, targetCurrency, true)
+	result.IncomeByCategory, err = r.getCategoryStats(ctx, userID, filter, true, filter.Depth)
+	if err != nil {
+		return result, err
+	}
+
+	result.ExpenseByCategory, err = r.getCategoryStats(ctx, userID, filter, false, filter.Depth)
 	if err != nil {
 		return result, err
 	}
 
-	result.ExpenseByCategory, err = r.getCategoryStats(userID, from, to, accountID, targetCurrency, false)
+	result.IncomeByTag, err = r.getTagStats(ctx, userID, filter, true)
 	if err != nil {
 		return result, err
 	}
 
-	result.DailyStats, err = r.getDailyStats(userID, from, to, accountID, targetCurrency)
+	result.ExpenseByTag, err = r.getTagStats(ctx, userID, filter, false)
+	if err != nil {
+		return result, err
+	}
+
+	result.DailyStats, err = r.getDailyStats(ctx, userID, filter)
 	if err != nil {
 		return result, err
 	}
@@ -45,70 +74,98 @@ func (r *StatisticsRepo) GetStatistics(userID int, from, to string, accountID *i
 	return result, nil
 }
 
-type totalsResult struct {
-	income  float64
-	expense float64
+// GetExpenseByCategory — расходы пользователя по категориям за период [from, to] в targetCurrency.
+// Использует тот же пересчёт по курсам, что и GetStatistics (нужен для бюджетов).
+// Расходы каждой категории включают расходы всех её подкатегорий: бюджет «Еда» учитывает и «Рестораны».
+func (r *StatisticsRepo) GetExpenseByCategory(ctx context.Context, userID int, from, to string, targetCurrency string) ([]entity.CategoryStat, error) {
+	filter := entity.StatisticsFilter{From: from, To: to, Currency: targetCurrency}
+	tree, err := r.getCategoryStats(ctx, userID, filter, false, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := []entity.CategoryStat{}
+	var flatten func(nodes []entity.CategoryStat)
+	flatten = func(nodes []entity.CategoryStat) {
+		for _, n := range nodes {
+			children := n.Children
+			n.Children = nil
+			stats = append(stats, n)
+			flatten(children)
+		}
+	}
+	flatten(tree)
+	return stats, nil
 }
 
-func (r *StatisticsRepo) getTotals(userID int, from, to string, accountID *int, targetCurrency string) (totalsResult, error) {
+// statsFrom — общая часть запросов статистики: FROM с дополнительными joins и WHERE по фильтру.
+// Учитываются операции на всех счетах, доступных пользователю, включая совместные.
+// $1 — user_id, $2 — целевая валюта (на неё ссылается rateOnDate); остальные параметры добавляются по фильтру.
+func statsFrom(userID int, filter entity.StatisticsFilter, joins string) (string, []interface{}) {
+	args := []interface{}{userID, filter.Currency, filter.From, filter.To}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
 	query := `
-		SELECT
-			COALESCE(SUM(CASE WHEN t.amount > 0 THEN t.amount * (r_src.rate_to_usd / r_tgt.rate_to_usd) ELSE 0 END), 0) AS income,
-			COALESCE(SUM(CASE WHEN t.amount < 0 THEN ABS(t.amount) * (r_src.rate_to_usd / r_tgt.rate_to_usd) ELSE 0 END), 0) AS expense
 		FROM transactions t
 		JOIN accounts a ON t.account_id = a.id
 		JOIN rates r_src ON r_src.currency = a.currency
-		JOIN rates r_tgt ON r_tgt.currency = $2
-		WHERE a.user_id = $1
+		JOIN rates r_tgt ON r_tgt.currency = $2` + joins + `
+		WHERE ` + accountAccessible("$1") + `
 		  AND t.deleted_at IS NULL
+		  AND t.transfer_id IS NULL
 		  AND a.deleted_at IS NULL
 		  AND t.created_at >= $3
 		  AND t.created_at < ($4::date + interval '1 day')`
 
-	args := []interface{}{userID, targetCurrency, from, to}
-
-	if accountID != nil {
-		query += " AND t.account_id = $5"
-		args = append(args, *accountID)
+	if filter.AccountID != nil {
+		query += " AND t.account_id = " + arg(*filter.AccountID)
+	}
+	if len(filter.Tags) > 0 {
+		query += " AND " + hasAllTags(arg(pq.Array(filter.Tags)))
 	}
+	return query, args
+}
+
+type totalsResult struct {
+	income  entity.Money
+	expense entity.Money
+}
+
+func (r *StatisticsRepo) getTotals(ctx context.Context, userID int, filter entity.StatisticsFilter) (totalsResult, error) {
+	from, args := statsFrom(userID, filter, "")
+	query := `
+		SELECT
+			COALESCE(SUM(CASE WHEN t.amount > 0 THEN t.amount * ` + rateOnDate + ` ELSE 0 END), 0) AS income,
+			COALESCE(SUM(CASE WHEN t.amount < 0 THEN ABS(t.amount) * ` + rateOnDate + ` ELSE 0 END), 0) AS expense` + from
 
 	var res totalsResult
-	err := r.db.QueryRow(query, args...).Scan(&res.income, &res.expense)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&res.income, &res.expense)
 	return res, err
 }
 
-func (r *StatisticsRepo) getCategoryStats(userID int, from, to string, accountID *int, targetCurrency string, isIncome bool) ([]entity.CategoryStat, error) {
-	amountCondition := "t.amount > 0"
-	sumExpr := "COALESCE(SUM(t.amount * (r_src.rate_to_usd / r_tgt.rate_to_usd)), 0)"
+// getCategoryStats — суммы по категориям. depth == 0 — плоский список (у каждой категории только её операции),
+// depth > 0 — дерево глубиной depth, depth < 0 — дерево без ограничения глубины.
+func (r *StatisticsRepo) getCategoryStats(ctx context.Context, userID int, filter entity.StatisticsFilter, isIncome bool, depth int) ([]entity.CategoryStat, error) {
+	// Операция с разбивкой учитывается частями, каждая — в своей категории (см. splitLines).
+	amountCondition := "l.amount > 0"
+	sumExpr := "COALESCE(SUM(l.amount * " + rateOnDate + "), 0)"
 	if !isIncome {
-		amountCondition = "t.amount < 0"
-		sumExpr = "COALESCE(SUM(ABS(t.amount) * (r_src.rate_to_usd / r_tgt.rate_to_usd)), 0)"
+		amountCondition = "l.amount < 0"
+		sumExpr = "COALESCE(SUM(ABS(l.amount) * " + rateOnDate + "), 0)"
 	}
 
+	from, args := statsFrom(userID, filter, splitLines+`
+		LEFT JOIN categories c ON l.category_id = c.id`)
 	query := `
-		SELECT t.category_id, COALESCE(c.name, 'Без категории'), ` + sumExpr + `, COUNT(*)
-		FROM transactions t
-		JOIN accounts a ON t.account_id = a.id
-		JOIN rates r_src ON r_src.currency = a.currency
-		JOIN rates r_tgt ON r_tgt.currency = $2
-		LEFT JOIN categories c ON t.category_id = c.id
-		WHERE a.user_id = $1
-		  AND t.deleted_at IS NULL
-		  AND a.deleted_at IS NULL
-		  AND t.created_at >= $3
-		  AND t.created_at < ($4::date + interval '1 day')
-		  AND ` + amountCondition
-
-	args := []interface{}{userID, targetCurrency, from, to}
+		SELECT l.category_id, COALESCE(c.name, 'Без категории'), ` + sumExpr + `, COUNT(DISTINCT t.id)` +
+		from + `
+		  AND ` + amountCondition + `
+		GROUP BY l.category_id, c.name ORDER BY ` + sumExpr + " DESC"
 
-	if accountID != nil {
-		query += " AND t.account_id = $5"
-		args = append(args, *accountID)
-	}
-
-	query += " GROUP BY t.category_id, c.name ORDER BY " + sumExpr + " DESC"
-
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -122,39 +179,180 @@ func (r *StatisticsRepo) getCategoryStats(userID int, from, to string, accountID
 		}
 		stats = append(stats, s)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	if stats == nil {
 		stats = []entity.CategoryStat{}
 	}
-	return stats, nil
+	if depth == 0 {
+		return stats, nil
+	}
+
+	categories, err := r.getCategoryParents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return rollUpCategories(stats, categories, depth), nil
 }
 
-func (r *StatisticsRepo) getDailyStats(userID int, from, to string, accountID *int, targetCurrency string) ([]entity.DailyStat, error) {
+// getTagStats — суммы по тегам. Операция с несколькими тегами учитывается в каждом.
+// Теги группируются по названию, поэтому тег владельца совместного счёта и одноимённый тег
+// участника — одна строка, как и в TagRepo.GetAllByUserID.
+func (r *StatisticsRepo) getTagStats(ctx context.Context, userID int, filter entity.StatisticsFilter, isIncome bool) ([]entity.TagStat, error) {
+	amountCondition := "t.amount > 0"
+	sumExpr := "COALESCE(SUM(t.amount * " + rateOnDate + "), 0)"
+	if !isIncome {
+		amountCondition = "t.amount < 0"
+		sumExpr = "COALESCE(SUM(ABS(t.amount) * " + rateOnDate + "), 0)"
+	}
+
+	from, args := statsFrom(userID, filter, `
+		JOIN transaction_tags ttg ON ttg.transaction_id = t.id
+		JOIN tags tg ON tg.id = ttg.tag_id`)
 	query := `
-		SELECT
-			DATE(t.created_at)::text AS day,
-			COALESCE(SUM(CASE WHEN t.amount > 0 THEN t.amount * (r_src.rate_to_usd / r_tgt.rate_to_usd) ELSE 0 END), 0) AS income,
-			COALESCE(SUM(CASE WHEN t.amount < 0 THEN ABS(t.amount) * (r_src.rate_to_usd / r_tgt.rate_to_usd) ELSE 0 END), 0) AS expense
-		FROM transactions t
-		JOIN accounts a ON t.account_id = a.id
-		JOIN rates r_src ON r_src.currency = a.currency
-		JOIN rates r_tgt ON r_tgt.currency = $2
-		WHERE a.user_id = $1
-		  AND t.deleted_at IS NULL
-		  AND a.deleted_at IS NULL
-		  AND t.created_at >= $3
-		  AND t.created_at < ($4::date + interval '1 day')`
+		SELECT tg.name, ` + sumExpr + `, COUNT(*)` +
+		from + `
+		  AND ` + amountCondition + `
+		GROUP BY tg.name ORDER BY ` + sumExpr + " DESC, tg.name"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := []entity.TagStat{}
+	for rows.Next() {
+		var s entity.TagStat
+		if err := rows.Scan(&s.Tag, &s.Total, &s.Count); err != nil {
+			return nil, err
+		}
+		stats = append(stats, s)
+	}
+	return stats, rows.Err()
+}
+
+// getCategoryParents — родители всех категорий пользователя, включая удалённые:
+// их операции по-прежнему попадают в статистику.
+func (r *StatisticsRepo) getCategoryParents(ctx context.Context, userID int) (map[int]categoryParent, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, parent_id FROM categories WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := map[int]categoryParent{}
+	for rows.Next() {
+		var id int
+		var c categoryParent
+		if err := rows.Scan(&id, &c.name, &c.parentID); err != nil {
+			return nil, err
+		}
+		categories[id] = c
+	}
+	return categories, rows.Err()
+}
 
-	args := []interface{}{userID, targetCurrency, from, to}
+// categoryParent — название и родитель категории для построения дерева статистики.
+type categoryParent struct {
+	name     string
+	parentID *int
+}
 
-	if accountID != nil {
-		query += " AND t.account_id = $5"
-		args = append(args, *accountID)
+// rollUpCategories — собрать плоскую статистику по категориям в дерево.
+// Total и Count каждого узла включают все его подкатегории; узлы глубже depth
+// в ответ не попадают, но их суммы учтены в предке (depth < 0 — без ограничения).
+// Ветки без операций отбрасываются. Операции без категории — отдельный корневой узел;
+// категории не из categories (другого участника совместного счёта) — тоже корневые узлы,
+// чтобы суммы дерева сходились с итогами.
+func rollUpCategories(flat []entity.CategoryStat, categories map[int]categoryParent, depth int) []entity.CategoryStat {
+	own := map[int]entity.CategoryStat{}
+	var roots []entity.CategoryStat
+	for _, s := range flat {
+		if s.CategoryID == nil {
+			roots = append(roots, s)
+			continue
+		}
+		if _, ok := categories[*s.CategoryID]; !ok {
+			roots = append(roots, s)
+			continue
+		}
+		own[*s.CategoryID] = s
 	}
 
-	query += " GROUP BY DATE(t.created_at) ORDER BY DATE(t.created_at)"
+	children := map[int][]int{}
+	var rootIDs []int
+	for id, c := range categories {
+		if c.parentID != nil {
+			if _, ok := categories[*c.parentID]; ok {
+				children[*c.parentID] = append(children[*c.parentID], id)
+				continue
+			}
+		}
+		rootIDs = append(rootIDs, id)
+	}
+
+	// visited защищает от зацикленных parent_id, если они всё же оказались в БД.
+	visited := map[int]bool{}
+	var build func(id, level int) entity.CategoryStat
+	build = func(id, level int) entity.CategoryStat {
+		visited[id] = true
+		node := own[id]
+		node.CategoryID = &id
+		node.CategoryName = categories[id].name
+		for _, childID := range children[id] {
+			if visited[childID] {
+				continue
+			}
+			child := build(childID, level+1)
+			if child.Count == 0 {
+				continue
+			}
+			node.Total += child.Total
+			node.Count += child.Count
+			if depth < 0 || level < depth {
+				node.Children = append(node.Children, child)
+			}
+		}
+		sortCategoryStats(node.Children)
+		return node
+	}
+
+	for _, id := range rootIDs {
+		if node := build(id, 1); node.Count > 0 {
+			roots = append(roots, node)
+		}
+	}
+	if roots == nil {
+		roots = []entity.CategoryStat{}
+	}
+	sortCategoryStats(roots)
+	return roots
+}
+
+// sortCategoryStats — по убыванию суммы, как в плоской статистике.
+func sortCategoryStats(stats []entity.CategoryStat) {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Total != stats[j].Total {
+			return stats[i].Total > stats[j].Total
+		}
+		return stats[i].CategoryName < stats[j].CategoryName
+	})
+}
+
+func (r *StatisticsRepo) getDailyStats(ctx context.Context, userID int, filter entity.StatisticsFilter) ([]entity.DailyStat, error) {
+	from, args := statsFrom(userID, filter, "")
+	query := `
+		SELECT
+			DATE(t.created_at)::text AS day,
+			COALESCE(SUM(CASE WHEN t.amount > 0 THEN t.amount * ` + rateOnDate + ` ELSE 0 END), 0) AS income,
+			COALESCE(SUM(CASE WHEN t.amount < 0 THEN ABS(t.amount) * ` + rateOnDate + ` ELSE 0 END), 0) AS expense` +
+		from + `
+		GROUP BY DATE(t.created_at) ORDER BY DATE(t.created_at)`
 
-	rows, err := r.db.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
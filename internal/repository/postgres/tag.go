@@ -0,0 +1,94 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"vue-calc/internal/entity"
+)
+
+// transactionTags — теги операции t в алфавитном порядке (пустой массив, если тегов нет).
+// Сканируется через pq.Array(&t.Tags).
+const transactionTags = `COALESCE((SELECT array_agg(tg.name ORDER BY tg.name)
+			FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id
+			WHERE tt.transaction_id = t.id), '{}')`
+
+// hasAllTags — условие «у операции t есть все теги из массива param».
+func hasAllTags(param string) string {
+	return `(SELECT COUNT(*) FROM transaction_tags tt JOIN tags tg ON tg.id = tt.tag_id
+		WHERE tt.transaction_id = t.id AND tg.name = ANY(` + param + `)) = cardinality(` + param + `::text[])`
+}
+
+// TagRepo — репозиторий тегов в PostgreSQL.
+type TagRepo struct {
+	db *sql.DB
+}
+
+// NewTagRepo — конструктор репозитория тегов.
+func NewTagRepo(db *sql.DB) *TagRepo {
+	return &TagRepo{db: db}
+}
+
+// GetAllByUserID — теги пользователя и теги операций на доступных ему совместных счетах
+// (они хранятся у владельца счёта, см. setTransactionTags) с числом операций, на которых они стоят.
+// Теги объединяются по названию, как в статистике; ID — собственного тега пользователя, если он есть.
+// Удалённые операции и операции удалённых счетов не считаются; неиспользуемые теги имеют Count = 0.
+func (r *TagRepo) GetAllByUserID(ctx context.Context, userID int) ([]entity.Tag, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT COALESCE(MIN(tg.id) FILTER (WHERE tg.user_id = $1), MIN(tg.id)), tg.name, COUNT(DISTINCT t.id)
+		FROM tags tg
+		LEFT JOIN transaction_tags tt ON tt.tag_id = tg.id
+		LEFT JOIN (transactions t JOIN accounts a ON a.id = t.account_id AND a.deleted_at IS NULL AND `+accountAccessible("$1")+`)
+		       ON t.id = tt.transaction_id AND t.deleted_at IS NULL
+		WHERE tg.user_id = $1 OR t.id IS NOT NULL
+		GROUP BY tg.name
+		ORDER BY COUNT(DISTINCT t.id) DESC, tg.name`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []entity.Tag{}
+	for rows.Next() {
+		var t entity.Tag
+		if err := rows.Scan(&t.ID, &t.Name, &t.Count); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// setTransactionTags — заменить теги операции на tags.
+// Недостающие теги создаются у владельца счёта операции: теги совместного счёта принадлежат
+// его владельцу, кто бы из участников их ни поставил. Участники видят их в GetAllByUserID.
+func setTransactionTags(ctx context.Context, q querier, transactionID, accountID int, tags []string) error {
+	if _, err := q.ExecContext(ctx, "DELETE FROM transaction_tags WHERE transaction_id = $1", transactionID); err != nil {
+		return err
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+
+	_, err := q.ExecContext(ctx, `
+		INSERT INTO tags (user_id, name)
+		SELECT a.user_id, unnest($1::text[]) FROM accounts a WHERE a.id = $2
+		ON CONFLICT (user_id, name) DO NOTHING`,
+		pq.Array(tags), accountID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ExecContext(ctx, `
+		INSERT INTO transaction_tags (transaction_id, tag_id)
+		SELECT $1, tg.id FROM tags tg JOIN accounts a ON a.user_id = tg.user_id
+		WHERE a.id = $2 AND tg.name = ANY($3)`,
+		transactionID, accountID, pq.Array(tags),
+	)
+	return err
+}
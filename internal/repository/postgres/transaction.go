@@ -1,10 +1,24 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+
 	"vue-calc/internal/entity"
 )
 
+// querier — общее подмножество методов *sql.DB и *sql.Tx.
+// Позволяет выполнять одни и те же запросы как напрямую, так и внутри транзакции БД.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 // TransactionRepo — репозиторий для работы с операциями (транзакциями) в PostgreSQL.
 type TransactionRepo struct {
 	db *sql.DB
@@ -15,16 +29,70 @@ func NewTransactionRepo(db *sql.DB) *TransactionRepo {
 	return &TransactionRepo{db: db}
 }
 
-// GetByAccountID — получить все транзакции по счёту, новые сверху (ORDER BY created_at DESC).
-func (r *TransactionRepo) GetByAccountID(accountID int) ([]entity.Transaction, error) {
-	rows, err := r.db.Query(`
-		SELECT t.id, t.account_id, t.amount, t.comment, t.category_id, COALESCE(c.name, ''), t.created_at
+// GetByAccountID — получить страницу транзакций по счёту с фильтрами и сортировкой.
+// Пагинация — по ключу (keyset): следующая страница начинается строго после filter.Cursor,
+// поэтому глубокие страницы не требуют OFFSET и не «съезжают» при вставке новых операций.
+func (r *TransactionRepo) GetByAccountID(ctx context.Context, accountID int, filter entity.TransactionFilter) ([]entity.Transaction, error) {
+	args := []interface{}{accountID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	query := `
+		SELECT t.id, t.account_id, t.amount, t.comment, t.category_id, COALESCE(c.name, ''), ` + transactionTags + `,
+		       ` + attachmentCount + `, t.transfer_id, t.created_at, t.created_by
 		FROM transactions t
 		LEFT JOIN categories c ON t.category_id = c.id
-		WHERE t.account_id = $1 AND t.deleted_at IS NULL
-		ORDER BY t.created_at DESC`,
-		accountID,
-	)
+		WHERE t.account_id = $1 AND t.deleted_at IS NULL`
+
+	if filter.From != "" {
+		query += " AND t.created_at >= " + arg(filter.From)
+	}
+	if filter.To != "" {
+		query += " AND t.created_at < (" + arg(filter.To) + "::date + interval '1 day')"
+	}
+	query += categoryCondition(filter.Uncategorized, filter.CategoryID, arg)
+	if filter.MinAmount != nil {
+		query += " AND ABS(t.amount) >= " + arg(*filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query += " AND ABS(t.amount) <= " + arg(*filter.MaxAmount)
+	}
+	switch filter.Sign {
+	case "income":
+		query += " AND t.amount > 0"
+	case "expense":
+		query += " AND t.amount < 0"
+	}
+	if filter.Comment != "" {
+		query += ` AND t.comment ILIKE '%' || ` + arg(escapeLike(filter.Comment)) + ` || '%'`
+	}
+	if len(filter.Tags) > 0 {
+		query += " AND " + hasAllTags(arg(pq.Array(filter.Tags)))
+	}
+
+	// Колонка сортировки и направление; id — второй ключ, чтобы порядок был строгим.
+	column, cast, op, dir := "t.created_at", "::timestamp", "<", "DESC"
+	switch filter.Sort {
+	case entity.SortDateAsc:
+		op, dir = ">", "ASC"
+	case entity.SortAmountDesc:
+		column, cast = "t.amount", "::numeric"
+	case entity.SortAmountAsc:
+		column, cast, op, dir = "t.amount", "::numeric", ">", "ASC"
+	}
+
+	if filter.Cursor != nil {
+		query += " AND (" + column + ", t.id) " + op + " (" + arg(filter.Cursor.Value) + cast + ", " + arg(filter.Cursor.ID) + ")"
+	}
+
+	query += " ORDER BY " + column + " " + dir + ", t.id " + dir
+	if filter.Limit > 0 {
+		query += " LIMIT " + arg(filter.Limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -33,66 +101,378 @@ func (r *TransactionRepo) GetByAccountID(accountID int) ([]entity.Transaction, e
 	transactions := []entity.Transaction{}
 	for rows.Next() {
 		var t entity.Transaction
-		if err := rows.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Comment, &t.CategoryID, &t.Category, &t.CreatedAt); err != nil {
+		if err := rows.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Comment, &t.CategoryID, &t.Category, pq.Array(&t.Tags),
+			&t.AttachmentCount, &t.TransferID, &t.CreatedAt, &t.CreatedBy); err != nil {
 			return nil, err
 		}
 		transactions = append(transactions, t)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if err := loadSplits(ctx, r.db, transactions); err != nil {
+		return nil, err
+	}
 	return transactions, nil
 }
 
+// loadSplits — заполнить разбивку у операций одним запросом.
+func loadSplits(ctx context.Context, q querier, transactions []entity.Transaction) error {
+	ids := make([]int, len(transactions))
+	for i, t := range transactions {
+		ids[i] = t.ID
+	}
+	splits, err := getSplits(ctx, q, ids)
+	if err != nil {
+		return err
+	}
+	for i := range transactions {
+		transactions[i].Splits = splits[transactions[i].ID]
+	}
+	return nil
+}
+
+// escapeLike экранирует спецсимволы LIKE, чтобы строка искалась буквально.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 // Delete — мягко удалить транзакцию по ID и account_id.
-func (r *TransactionRepo) Delete(id, accountID int) error {
-	res, err := r.db.Exec(
-		"UPDATE transactions SET deleted_at = NOW() WHERE id = $1 AND account_id = $2 AND deleted_at IS NULL",
+// Если транзакция — половина перевода, вместе с ней удаляется и вторая половина.
+// Каждая удалённая операция записывается в журнал изменений от имени пользователя userID.
+func (r *TransactionRepo) Delete(ctx context.Context, id, accountID, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE transactions t SET deleted_at = NOW()
+		WHERE t.deleted_at IS NULL
+		  AND ((t.id = $1 AND t.account_id = $2)
+		    OR t.transfer_id = (SELECT transfer_id FROM transactions WHERE id = $1 AND account_id = $2 AND deleted_at IS NULL))
+		RETURNING t.id, t.account_id, t.amount, t.comment, t.category_id,
+		          COALESCE((SELECT name FROM categories c WHERE c.id = t.category_id), ''), `+transactionTags+`,
+		          t.transfer_id, t.created_at, t.created_by`,
 		id, accountID,
 	)
 	if err != nil {
 		return err
 	}
-	rows, err := res.RowsAffected()
-	if err != nil {
+	deleted := []entity.Transaction{}
+	for rows.Next() {
+		var t entity.Transaction
+		if err := rows.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Comment, &t.CategoryID, &t.Category, pq.Array(&t.Tags), &t.TransferID, &t.CreatedAt, &t.CreatedBy); err != nil {
+			rows.Close()
+			return err
+		}
+		deleted = append(deleted, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
 		return err
 	}
-	if rows == 0 {
-		return sql.ErrNoRows
+	if len(deleted) == 0 {
+		return entity.ErrTransactionNotFound
 	}
-	return nil
+	if err := loadSplits(ctx, tx, deleted); err != nil {
+		return err
+	}
+
+	for _, t := range deleted {
+		if err := recordAudit(ctx, tx, userID, entity.AuditTransaction, t.ID, entity.AuditDelete, t, nil); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 // Update — обновить транзакцию по ID и account_id.
-func (r *TransactionRepo) Update(id, accountID int, transaction entity.Transaction) (entity.Transaction, error) {
-	err := r.db.QueryRow(`
+// Теги и разбивка заменяются, только если transaction.Tags и transaction.Splits не nil.
+// Прежнее состояние операции сохраняется в журнале изменений от имени пользователя userID.
+// Половина перевода не изменяется — entity.ErrTransferImmutable.
+func (r *TransactionRepo) Update(ctx context.Context, id, accountID, userID int, transaction entity.Transaction) (entity.Transaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	before, err := getTransactionForUpdate(ctx, tx, id, accountID)
+	if err != nil {
+		return entity.Transaction{}, notFound(err, entity.ErrTransactionNotFound)
+	}
+	if before.TransferID != nil {
+		return entity.Transaction{}, entity.ErrTransferImmutable
+	}
+
+	err = tx.QueryRowContext(ctx, `
 		UPDATE transactions SET amount=$1, comment=$2, category_id=$3, created_at=$4
 		WHERE id=$5 AND account_id=$6 AND deleted_at IS NULL
-		RETURNING id, account_id, amount, comment, category_id, created_at`,
+		RETURNING id, account_id, amount, comment, category_id, transfer_id, created_at, created_by`,
 		transaction.Amount, transaction.Comment, transaction.CategoryID, transaction.CreatedAt,
 		id, accountID,
-	).Scan(&transaction.ID, &transaction.AccountID, &transaction.Amount, &transaction.Comment, &transaction.CategoryID, &transaction.CreatedAt)
+	).Scan(&transaction.ID, &transaction.AccountID, &transaction.Amount, &transaction.Comment, &transaction.CategoryID, &transaction.TransferID, &transaction.CreatedAt, &transaction.CreatedBy)
 	if err != nil {
 		return entity.Transaction{}, err
 	}
 
 	// Fetch category name
 	if transaction.CategoryID != nil {
-		_ = r.db.QueryRow("SELECT name FROM categories WHERE id = $1 AND deleted_at IS NULL", *transaction.CategoryID).Scan(&transaction.Category)
+		_ = tx.QueryRowContext(ctx, "SELECT name FROM categories WHERE id = $1 AND deleted_at IS NULL", *transaction.CategoryID).Scan(&transaction.Category)
 	}
 
+	transaction.AttachmentCount = before.AttachmentCount
+	if transaction.Splits == nil {
+		transaction.Splits = before.Splits
+	} else if transaction.Splits, err = setTransactionSplits(ctx, tx, id, transaction.Splits); err != nil {
+		return entity.Transaction{}, err
+	}
+	if transaction.Tags == nil {
+		transaction.Tags = before.Tags
+	} else if err := setTransactionTags(ctx, tx, id, accountID, transaction.Tags); err != nil {
+		return entity.Transaction{}, err
+	}
+
+	if err := recordAudit(ctx, tx, userID, entity.AuditTransaction, id, entity.AuditUpdate, before, transaction); err != nil {
+		return entity.Transaction{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return entity.Transaction{}, err
+	}
 	return transaction, nil
 }
 
+// GetByID — неудалённая операция счёта вместе с разбивкой.
+func (r *TransactionRepo) GetByID(ctx context.Context, id, accountID int) (entity.Transaction, error) {
+	t, err := getTransaction(ctx, r.db, id, accountID, "")
+	if err != nil {
+		return entity.Transaction{}, notFound(err, entity.ErrTransactionNotFound)
+	}
+	return t, nil
+}
+
+// getTransactionForUpdate — прочитать операцию и заблокировать её строку до конца транзакции БД.
+func getTransactionForUpdate(ctx context.Context, q querier, id, accountID int) (entity.Transaction, error) {
+	return getTransaction(ctx, q, id, accountID, "FOR UPDATE OF t")
+}
+
+// getTransaction — неудалённая операция счёта с тегами, разбивкой и числом вложений;
+// lock — необязательная блокировка строки (FOR UPDATE ...).
+func getTransaction(ctx context.Context, q querier, id, accountID int, lock string) (entity.Transaction, error) {
+	var t entity.Transaction
+	err := q.QueryRowContext(ctx, `
+		SELECT t.id, t.account_id, t.amount, t.comment, t.category_id, COALESCE(c.name, ''), `+transactionTags+`,
+		       `+attachmentCount+`, t.transfer_id, t.created_at, t.created_by
+		FROM transactions t
+		LEFT JOIN categories c ON t.category_id = c.id
+		WHERE t.id = $1 AND t.account_id = $2 AND t.deleted_at IS NULL
+		`+lock,
+		id, accountID,
+	).Scan(&t.ID, &t.AccountID, &t.Amount, &t.Comment, &t.CategoryID, &t.Category, pq.Array(&t.Tags),
+		&t.AttachmentCount, &t.TransferID, &t.CreatedAt, &t.CreatedBy)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	splits, err := getSplits(ctx, q, []int{t.ID})
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+	t.Splits = splits[t.ID]
+	return t, nil
+}
+
 // Create — создать новую транзакцию (операцию) по счёту.
-func (r *TransactionRepo) Create(transaction entity.Transaction) (entity.Transaction, error) {
+func (r *TransactionRepo) Create(ctx context.Context, transaction entity.Transaction) (entity.Transaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+	defer tx.Rollback()
+
+	transaction, err = createTransaction(ctx, tx, transaction)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return entity.Transaction{}, err
+	}
+	return transaction, nil
+}
+
+// CreateBatch — создать несколько транзакций в одной транзакции БД: либо все, либо ни одной.
+// Вместе с ними в той же транзакции создаются категории newCategories; операция без CategoryID
+// с названием Category из newCategories (без учёта регистра) получает созданную категорию.
+func (r *TransactionRepo) CreateBatch(ctx context.Context, transactions []entity.Transaction, newCategories []entity.Category) ([]entity.Transaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	categoryIDs := make(map[string]int, len(newCategories))
+	for _, c := range newCategories {
+		c, err := createCategory(ctx, tx, c)
+		if err != nil {
+			return nil, err
+		}
+		categoryIDs[strings.ToLower(c.Name)] = c.ID
+	}
+
+	created := make([]entity.Transaction, 0, len(transactions))
+	for _, t := range transactions {
+		if id, ok := categoryIDs[strings.ToLower(t.Category)]; ok && t.CategoryID == nil && t.Category != "" {
+			t.CategoryID = &id
+		}
+		t, err := createTransaction(ctx, tx, t)
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, t)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// CreateTransfer — атомарно создать перевод: запись в transfers и две связанные транзакции.
+// Всё выполняется в одной транзакции БД: либо создаются обе операции, либо ни одной.
+func (r *TransactionRepo) CreateTransfer(ctx context.Context, transfer entity.Transfer) (entity.Transfer, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+	defer tx.Rollback()
+
+	if transfer.CreatedAt != "" {
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO transfers (user_id, from_account_id, to_account_id, rate, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
+			transfer.UserID, transfer.FromAccountID, transfer.ToAccountID, transfer.Rate, transfer.CreatedAt,
+		).Scan(&transfer.ID, &transfer.CreatedAt)
+	} else {
+		err = tx.QueryRowContext(ctx,
+			"INSERT INTO transfers (user_id, from_account_id, to_account_id, rate) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
+			transfer.UserID, transfer.FromAccountID, transfer.ToAccountID, transfer.Rate,
+		).Scan(&transfer.ID, &transfer.CreatedAt)
+	}
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+
+	// Обе половины перевода получают ту же дату, что и сам перевод.
+	transfer.From.TransferID = &transfer.ID
+	transfer.From.CreatedAt = transfer.CreatedAt
+	transfer.To.TransferID = &transfer.ID
+	transfer.To.CreatedAt = transfer.CreatedAt
+
+	if transfer.From, err = createTransaction(ctx, tx, transfer.From); err != nil {
+		return entity.Transfer{}, err
+	}
+	if transfer.To, err = createTransaction(ctx, tx, transfer.To); err != nil {
+		return entity.Transfer{}, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return entity.Transfer{}, err
+	}
+	return transfer, nil
+}
+
+// createTransaction — вставка одной транзакции с её тегами и разбивкой через *sql.DB или *sql.Tx.
+// Создание записывается в журнал изменений, поэтому q должен быть транзакцией БД,
+// чтобы операция и запись журнала сохранялись вместе. Автор (CreatedBy) обязателен:
+// от его имени пишется запись журнала.
+func createTransaction(ctx context.Context, q querier, transaction entity.Transaction) (entity.Transaction, error) {
+	if transaction.CreatedBy == nil {
+		return entity.Transaction{}, errNoAuthor
+	}
+	var err error
 	if transaction.CreatedAt != "" {
-		err := r.db.QueryRow(
-			"INSERT INTO transactions (account_id, amount, comment, category_id, created_at) VALUES ($1, $2, $3, $4, $5) RETURNING id, created_at",
-			transaction.AccountID, transaction.Amount, transaction.Comment, transaction.CategoryID, transaction.CreatedAt,
+		err = q.QueryRowContext(ctx,
+			"INSERT INTO transactions (account_id, amount, comment, category_id, transfer_id, created_by, created_at) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at",
+			transaction.AccountID, transaction.Amount, transaction.Comment, transaction.CategoryID, transaction.TransferID, transaction.CreatedBy, transaction.CreatedAt,
+		).Scan(&transaction.ID, &transaction.CreatedAt)
+	} else {
+		err = q.QueryRowContext(ctx,
+			"INSERT INTO transactions (account_id, amount, comment, category_id, transfer_id, created_by) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id, created_at",
+			transaction.AccountID, transaction.Amount, transaction.Comment, transaction.CategoryID, transaction.TransferID, transaction.CreatedBy,
 		).Scan(&transaction.ID, &transaction.CreatedAt)
-		return transaction, err
 	}
-	err := r.db.QueryRow(
-		"INSERT INTO transactions (account_id, amount, comment, category_id) VALUES ($1, $2, $3, $4) RETURNING id, created_at",
-		transaction.AccountID, transaction.Amount, transaction.Comment, transaction.CategoryID,
-	).Scan(&transaction.ID, &transaction.CreatedAt)
-	return transaction, err
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+
+	if transaction.Tags == nil {
+		transaction.Tags = []string{}
+	} else if err := setTransactionTags(ctx, q, transaction.ID, transaction.AccountID, transaction.Tags); err != nil {
+		return entity.Transaction{}, err
+	}
+	if len(transaction.Splits) == 0 {
+		transaction.Splits = []entity.TransactionSplit{}
+	} else if transaction.Splits, err = setTransactionSplits(ctx, q, transaction.ID, transaction.Splits); err != nil {
+		return entity.Transaction{}, err
+	}
+
+	if err := recordAudit(ctx, q, *transaction.CreatedBy, entity.AuditTransaction, transaction.ID, entity.AuditCreate, nil, transaction); err != nil {
+		return entity.Transaction{}, err
+	}
+	return transaction, nil
+}
+
+// ForEachByUser — пройти по всем операциям на счетах пользователя (своих и совместных), подходящим под фильтр,
+// в хронологическом порядке. Строки читаются из курсора БД по одной и сразу
+// передаются в fn, поэтому выгрузка любого размера не загружается в память целиком.
+// У разбитой операции в категории перечислены категории её частей.
+func (r *TransactionRepo) ForEachByUser(ctx context.Context, userID int, filter entity.ExportFilter, fn func(entity.ExportRow) error) error {
+	args := []interface{}{userID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	query := `
+		SELECT t.id, t.account_id, t.amount, t.comment, t.category_id,
+		       COALESCE(c.name, (SELECT string_agg(COALESCE(sc.name, 'Без категории'), ', ' ORDER BY s.id)
+		                         FROM transaction_splits s LEFT JOIN categories sc ON sc.id = s.category_id
+		                         WHERE s.transaction_id = t.id), ''),
+		       ` + transactionTags + `, t.transfer_id,
+		       to_char(t.created_at, 'YYYY-MM-DD HH24:MI:SS'), a.currency, a.comment
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		LEFT JOIN categories c ON t.category_id = c.id
+		WHERE ` + accountAccessible("$1") + ` AND t.deleted_at IS NULL AND a.deleted_at IS NULL`
+
+	if filter.AccountID != nil {
+		query += " AND t.account_id = " + arg(*filter.AccountID)
+	}
+	if filter.From != "" {
+		query += " AND t.created_at >= " + arg(filter.From)
+	}
+	if filter.To != "" {
+		query += " AND t.created_at < (" + arg(filter.To) + "::date + interval '1 day')"
+	}
+	query += categoryCondition(filter.Uncategorized, filter.CategoryID, arg)
+	query += " ORDER BY t.created_at, t.id"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var row entity.ExportRow
+		if err := rows.Scan(&row.ID, &row.AccountID, &row.Amount, &row.Comment, &row.CategoryID, &row.Category,
+			pq.Array(&row.Tags), &row.TransferID, &row.CreatedAt, &row.Currency, &row.AccountComment); err != nil {
+			return err
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
 }
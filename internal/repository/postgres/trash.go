@@ -0,0 +1,362 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"vue-calc/internal/entity"
+)
+
+// TrashRepo — репозиторий корзины: чтение, восстановление и окончательное удаление
+// мягко удалённых счетов, категорий и операций.
+type TrashRepo struct {
+	db *sql.DB
+}
+
+// NewTrashRepo — конструктор репозитория корзины.
+func NewTrashRepo(db *sql.DB) *TrashRepo {
+	return &TrashRepo{db: db}
+}
+
+// GetAccounts — удалённые счета пользователя с операциями, удалёнными вместе с ними.
+func (r *TrashRepo) GetAccounts(ctx context.Context, userID int) ([]entity.TrashedAccount, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT a.id, a.user_id, a.currency, a.comment, a.created_at, a.deleted_at,
+		       COALESCE(SUM(t.amount), 0), COUNT(t.id)
+		FROM accounts a
+		LEFT JOIN transactions t ON t.account_id = a.id AND t.deleted_with_account
+		WHERE a.user_id = $1 AND a.deleted_at IS NOT NULL
+		GROUP BY a.id
+		ORDER BY a.deleted_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []entity.TrashedAccount{}
+	for rows.Next() {
+		var a entity.TrashedAccount
+		if err := rows.Scan(&a.ID, &a.UserID, &a.Currency, &a.Comment, &a.CreatedAt, &a.DeletedAt, &a.Balance, &a.Transactions); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, rows.Err()
+}
+
+// GetCategories — удалённые категории пользователя.
+func (r *TrashRepo) GetCategories(ctx context.Context, userID int) ([]entity.TrashedCategory, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, user_id, name, parent_id, created_at, deleted_at
+		FROM categories
+		WHERE user_id = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []entity.TrashedCategory{}
+	for rows.Next() {
+		var c entity.TrashedCategory
+		if err := rows.Scan(&c.ID, &c.UserID, &c.Name, &c.ParentID, &c.CreatedAt, &c.DeletedAt); err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+	return categories, rows.Err()
+}
+
+// GetTransactions — операции на счетах пользователя и его совместных счетах, удалённые по одной.
+// Операции, удалённые вместе со счётом, здесь не показываются — они часть удалённого счёта.
+func (r *TrashRepo) GetTransactions(ctx context.Context, userID int) ([]entity.TrashedTransaction, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, t.account_id, t.amount, t.comment, t.category_id, COALESCE(c.name, ''), `+transactionTags+`,
+		       `+attachmentCount+`, t.transfer_id, t.created_at, t.created_by, t.deleted_at
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		LEFT JOIN categories c ON t.category_id = c.id
+		WHERE `+accountAccessible("$1")+` AND t.deleted_at IS NOT NULL AND NOT t.deleted_with_account
+		ORDER BY t.deleted_at DESC, t.id DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []entity.TrashedTransaction{}
+	for rows.Next() {
+		var t entity.TrashedTransaction
+		if err := rows.Scan(&t.ID, &t.AccountID, &t.Amount, &t.Comment, &t.CategoryID, &t.Category, pq.Array(&t.Tags), &t.AttachmentCount, &t.TransferID, &t.CreatedAt, &t.CreatedBy, &t.DeletedAt); err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// GetTransactionAccount — счёт операции из корзины, доступной пользователю
+// (entity.ErrTrashItemNotFound, если такой операции в корзине нет).
+func (r *TrashRepo) GetTransactionAccount(ctx context.Context, id, userID int) (int, error) {
+	var accountID int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT t.account_id
+		FROM transactions t
+		JOIN accounts a ON t.account_id = a.id
+		WHERE t.id = $1 AND `+accountAccessible("$2")+` AND t.deleted_at IS NOT NULL AND NOT t.deleted_with_account`,
+		id, userID,
+	).Scan(&accountID)
+	return accountID, notFound(err, entity.ErrTrashItemNotFound)
+}
+
+// RestoreAccount — восстановить счёт и только те операции, что были удалены вместе с ним.
+func (r *TrashRepo) RestoreAccount(ctx context.Context, id, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE accounts SET deleted_at = NULL WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL",
+		id, userID,
+	)
+	if err := requireAffected(res, err); err != nil {
+		return notFound(err, entity.ErrTrashItemNotFound)
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE transactions SET deleted_at = NULL, deleted_with_account = FALSE WHERE account_id = $1 AND deleted_with_account",
+		id,
+	); err != nil {
+		return err
+	}
+	if err := recordAudit(ctx, tx, userID, entity.AuditAccount, id, entity.AuditRestore, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RestoreCategory — восстановить категорию. entity.ErrCategoryExists, если название уже занято.
+func (r *TrashRepo) RestoreCategory(ctx context.Context, id, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE categories SET deleted_at = NULL WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL",
+		id, userID,
+	)
+	if isUniqueViolation(err) {
+		return entity.ErrCategoryExists
+	}
+	if err := requireAffected(res, err); err != nil {
+		return notFound(err, entity.ErrTrashItemNotFound)
+	}
+	if err := recordAudit(ctx, tx, userID, entity.AuditCategory, id, entity.AuditRestore, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RestoreTransaction — восстановить операцию; для перевода восстанавливаются обе половины
+// (так же, как TransactionRepo.Delete удаляет обе). Половина на удалённом счёте или на счёте,
+// где пользователь не владелец и не редактор, остаётся в корзине.
+func (r *TrashRepo) RestoreTransaction(ctx context.Context, id, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		UPDATE transactions SET deleted_at = NULL
+		WHERE deleted_at IS NOT NULL AND NOT deleted_with_account
+		  AND account_id IN (SELECT a.id FROM accounts a WHERE a.deleted_at IS NULL AND `+accountEditable("$2")+`)
+		  AND (id = $1 OR transfer_id = (SELECT transfer_id FROM transactions WHERE id = $1))
+		RETURNING id`,
+		id, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := recordTrashAudit(ctx, tx, rows, userID, entity.AuditTransaction, entity.AuditRestore); err != nil {
+		return notFound(err, entity.ErrTrashItemNotFound)
+	}
+	return tx.Commit()
+}
+
+// PurgeAccount — окончательно удалить счёт из корзины вместе со всеми его операциями,
+// их вложениями и повторяющимися правилами (каскадом по внешним ключам).
+// Возвращает ключи файлов удалённых вложений.
+func (r *TrashRepo) PurgeAccount(ctx context.Context, id, userID int) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	keys, err := attachmentKeys(ctx, tx, `
+		SELECT t.id FROM transactions t JOIN accounts a ON t.account_id = a.id
+		WHERE a.id = $1 AND a.user_id = $2`, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := tx.ExecContext(ctx,
+		"DELETE FROM accounts WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL",
+		id, userID,
+	)
+	if err := requireAffected(res, err); err != nil {
+		return nil, notFound(err, entity.ErrTrashItemNotFound)
+	}
+	if err := deleteOrphanTransfers(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if err := recordAudit(ctx, tx, userID, entity.AuditAccount, id, entity.AuditPurge, nil, nil); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// PurgeCategory — окончательно удалить категорию из корзины.
+// Операции и правила остаются без категории, бюджеты категории удаляются.
+func (r *TrashRepo) PurgeCategory(ctx context.Context, id, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"DELETE FROM categories WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL",
+		id, userID,
+	)
+	if err := requireAffected(res, err); err != nil {
+		return notFound(err, entity.ErrTrashItemNotFound)
+	}
+	if err := recordAudit(ctx, tx, userID, entity.AuditCategory, id, entity.AuditPurge, nil, nil); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// purgeableTransactions — ID операций, которые окончательно удаляются вместе с операцией $1
+// по запросу пользователя $2: она сама и вторая половина перевода, если обе удалены по одной
+// и пользователь — владелец или редактор их счетов.
+var purgeableTransactions = `
+		SELECT id FROM transactions
+		WHERE deleted_at IS NOT NULL AND NOT deleted_with_account
+		  AND account_id IN (SELECT a.id FROM accounts a WHERE ` + accountEditable("$2") + `)
+		  AND (id = $1 OR transfer_id = (
+		    SELECT t.transfer_id FROM transactions t JOIN accounts a ON t.account_id = a.id
+		    WHERE t.id = $1 AND ` + accountEditable("$2") + `))`
+
+// PurgeTransaction — окончательно удалить операцию из корзины (для перевода — обе удалённые половины)
+// вместе с вложениями. Удалять может владелец или редактор счёта. Возвращает ключи файлов удалённых вложений.
+func (r *TrashRepo) PurgeTransaction(ctx context.Context, id, userID int) ([]string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	keys, err := attachmentKeys(ctx, tx, purgeableTransactions, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Журнал пишется до удаления: recordAudit берёт счёт операции из таблицы transactions.
+	rows, err := tx.QueryContext(ctx, purgeableTransactions, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := recordTrashAudit(ctx, tx, rows, userID, entity.AuditTransaction, entity.AuditPurge); err != nil {
+		return nil, notFound(err, entity.ErrTrashItemNotFound)
+	}
+	var transferID *int
+	if err := tx.QueryRowContext(ctx, "SELECT transfer_id FROM transactions WHERE id = $1", id).Scan(&transferID); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM transactions WHERE id IN ("+purgeableTransactions+")", id, userID); err != nil {
+		return nil, err
+	}
+	// Перевод мог создать другой участник счёта, поэтому удаляется именно этот перевод,
+	// если у него не осталось операций, а не «осиротевшие» переводы пользователя.
+	if transferID != nil {
+		if _, err := tx.ExecContext(ctx,
+			"DELETE FROM transfers tr WHERE tr.id = $1 AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.transfer_id = tr.id)",
+			*transferID,
+		); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// recordTrashAudit — записать в журнал действие над каждым объектом, ID которых вернул запрос.
+// Закрывает rows; sql.ErrNoRows, если запрос не затронул ни одной строки.
+func recordTrashAudit(ctx context.Context, q querier, rows *sql.Rows, userID int, entityType, action string) error {
+	ids := []int{}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return sql.ErrNoRows
+	}
+
+	for _, id := range ids {
+		if err := recordAudit(ctx, q, userID, entityType, id, action, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteOrphanTransfers — удалить записи переводов, у которых не осталось ни одной операции.
+func deleteOrphanTransfers(ctx context.Context, q querier, userID int) error {
+	_, err := q.ExecContext(ctx, `
+		DELETE FROM transfers tr
+		WHERE tr.user_id = $1 AND NOT EXISTS (SELECT 1 FROM transactions t WHERE t.transfer_id = tr.id)`,
+		userID,
+	)
+	return err
+}
+
+// requireAffected — sql.ErrNoRows, если запрос не затронул ни одной строки.
+func requireAffected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -1,7 +1,10 @@
 package postgres
 
 import (
+	"context"
 	"database/sql"
+	"time"
+
 	"vue-calc/internal/entity"
 )
 
@@ -16,21 +19,135 @@ func NewUserRepo(db *sql.DB) *UserRepo {
 }
 
 // Create — создать нового пользователя. Возвращает созданного пользователя с ID.
-func (r *UserRepo) Create(email, passwordHash string) (entity.User, error) {
+// entity.ErrEmailTaken, если email уже зарегистрирован.
+func (r *UserRepo) Create(ctx context.Context, email, passwordHash string) (entity.User, error) {
 	var user entity.User
-	err := r.db.QueryRow(
+	err := r.db.QueryRowContext(ctx,
 		"INSERT INTO users (email, password_hash) VALUES ($1, $2) RETURNING id, email, created_at",
 		email, passwordHash,
 	).Scan(&user.ID, &user.Email, &user.CreatedAt)
+	if isUniqueViolation(err) {
+		return entity.User{}, entity.ErrEmailTaken
+	}
 	return user, err
 }
 
-// GetByEmail — найти пользователя по email.
-func (r *UserRepo) GetByEmail(email string) (entity.User, error) {
+// GetByEmail — найти пользователя по email без учёта регистра (адреса, сохранённые
+// до приведения к нижнему регистру, тоже находятся).
+func (r *UserRepo) GetByEmail(ctx context.Context, email string) (entity.User, error) {
 	var user entity.User
-	err := r.db.QueryRow(
-		"SELECT id, email, password_hash, created_at FROM users WHERE email = $1",
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, email, email_verified_at IS NOT NULL, password_hash, created_at FROM users WHERE lower(email) = lower($1) ORDER BY id LIMIT 1",
 		email,
-	).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.CreatedAt)
-	return user, err
+	).Scan(&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash, &user.CreatedAt)
+	return user, notFound(err, entity.ErrUserNotFound)
+}
+
+// GetByID — найти пользователя по ID.
+func (r *UserRepo) GetByID(ctx context.Context, id int) (entity.User, error) {
+	var user entity.User
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, email, email_verified_at IS NOT NULL, password_hash, created_at FROM users WHERE id = $1",
+		id,
+	).Scan(&user.ID, &user.Email, &user.EmailVerified, &user.PasswordHash, &user.CreatedAt)
+	return user, notFound(err, entity.ErrUserNotFound)
+}
+
+// CreateToken — сохранить хэш одноразового токена с назначением purpose.
+// Выданные раньше неиспользованные токены того же назначения перестают действовать:
+// работает только ссылка из последнего письма.
+func (r *UserRepo) CreateToken(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE user_tokens SET used_at = NOW() WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL",
+		userID, purpose,
+	); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"INSERT INTO user_tokens (token_hash, user_id, purpose, expires_at) VALUES ($1, $2, $3, $4)",
+		tokenHash, userID, purpose, expiresAt,
+	); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// VerifyEmail — погасить токен подтверждения и отметить email пользователя подтверждённым.
+// entity.ErrInvalidLink, если токен не найден, истёк или уже использован.
+func (r *UserRepo) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	userID, err := useToken(ctx, tx, tokenHash, entity.TokenVerifyEmail)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE users SET email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $1",
+		userID,
+	); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+// ResetPassword — погасить токен сброса, сменить хэш пароля и отозвать все сессии пользователя:
+// кто бы ни знал старый пароль, его входы перестают действовать.
+// Сброс пароля подтверждает и email — ссылка пришла на этот адрес.
+// entity.ErrInvalidLink, если токен не найден, истёк или уже использован.
+func (r *UserRepo) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	userID, err := useToken(ctx, tx, tokenHash, entity.TokenResetPassword)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE users SET password_hash = $1, email_verified_at = COALESCE(email_verified_at, NOW()) WHERE id = $2",
+		passwordHash, userID,
+	); err != nil {
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx,
+		"UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL",
+		userID,
+	); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+// useToken — пометить действующий токен назначения purpose использованным и вернуть его пользователя.
+// Проверка и погашение — один UPDATE, поэтому токен нельзя использовать дважды даже параллельно.
+func useToken(ctx context.Context, q querier, tokenHash, purpose string) (int, error) {
+	var userID int
+	err := q.QueryRowContext(ctx, `
+		UPDATE user_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > $3
+		RETURNING user_id`,
+		tokenHash, purpose, time.Now(),
+	).Scan(&userID)
+	return userID, notFound(err, entity.ErrInvalidLink)
+}
+
+// RecordLoginFailure — записать неудачный вход в журнал.
+func (r *UserRepo) RecordLoginFailure(ctx context.Context, email, ip string) error {
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO login_failures (user_id, email, ip) VALUES ((SELECT id FROM users WHERE email = $1), $1, $2)",
+		email, ip,
+	)
+	return err
 }
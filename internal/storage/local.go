@@ -0,0 +1,83 @@
+// Package storage — реализации файлового хранилища вложений (usecase.FileStorage).
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrInvalidKey — ключ файла пустой или содержит путь.
+var ErrInvalidKey = errors.New("неверный ключ файла")
+
+// Local — хранилище файлов в каталоге локальной файловой системы.
+// Ключ файла — имя файла в каталоге; запись идёт во временный файл,
+// который переименовывается только после успешного сохранения.
+type Local struct {
+	dir string
+}
+
+// NewLocal — хранилище в каталоге dir; каталог создаётся, если его нет.
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("ошибка создания каталога вложений: %w", err)
+	}
+	return &Local{dir: dir}, nil
+}
+
+// Save — сохранить содержимое r под ключом key.
+// Если чтение r завершилось ошибкой, файл не создаётся.
+func (s *Local) Save(key string, r io.Reader) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Open — открыть файл по ключу на чтение.
+func (s *Local) Open(key string) (io.ReadCloser, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+// Delete — удалить файл по ключу. Отсутствующий файл не считается ошибкой.
+func (s *Local) Delete(key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// path — путь к файлу ключа; ключи с разделителями пути отклоняются,
+// чтобы нельзя было выйти за пределы каталога.
+func (s *Local) path(key string) (string, error) {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) || strings.HasPrefix(key, ".") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.dir, key), nil
+}
@@ -1,54 +1,176 @@
 package usecase
 
-import "vue-calc/internal/entity"
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"vue-calc/internal/entity"
+)
+
+var (
+	// ErrNotAccountOwner — действие доступно только владельцу счёта.
+	ErrNotAccountOwner = entity.NewError(entity.KindForbidden, "not_account_owner", "Действие доступно только владельцу счёта")
+	// ErrReadOnlyAccount — у пользователя доступ к счёту только на просмотр.
+	ErrReadOnlyAccount = entity.NewError(entity.KindForbidden, "read_only_account", "Доступ к счёту только на просмотр")
+	// ErrInvalidRole — роль участника должна быть viewer или editor.
+	ErrInvalidRole = entity.NewValidationError("invalid_role", "role", "Роль должна быть viewer или editor")
+	// ErrInvalidMember — владельца нельзя пригласить на его же счёт или удалить из участников.
+	ErrInvalidMember = entity.NewError(entity.KindValidation, "invalid_member", "Владелец счёта не может быть участником")
+)
 
 // AccountRepository — интерфейс репозитория счетов.
-// Все операции фильтруются по user_id.
+// Чтение доступно владельцу и участникам счёта, изменение самого счёта — только владельцу.
 type AccountRepository interface {
-	GetAll(userID int) ([]entity.Account, error)
-	GetByID(id, userID int) (entity.Account, error)
-	Create(account entity.Account) (entity.Account, error)
-	Delete(id, userID int) (int64, error)
-	Exists(id, userID int) (bool, error)
-	UpdateComment(id, userID int, comment string) error
+	GetAll(ctx context.Context, userID int) ([]entity.Account, error)
+	GetByID(ctx context.Context, id, userID int) (entity.Account, error)
+	Create(ctx context.Context, account entity.Account) (entity.Account, error)
+	Delete(ctx context.Context, id, userID int) error
+	Exists(ctx context.Context, id, userID int) (bool, error)
+	GetRole(ctx context.Context, id, userID int) (string, error)
+	UpdateComment(ctx context.Context, id, userID int, comment string) error
+	GetCurrency(ctx context.Context, id int) (string, error)
+	GetMembers(ctx context.Context, accountID int) ([]entity.AccountMember, error)
+	SetMember(ctx context.Context, accountID, userID int, role string) (entity.AccountMember, error)
+	RemoveMember(ctx context.Context, accountID, userID int) error
 }
 
 // AccountUseCase — бизнес-логика для работы со счетами.
 type AccountUseCase struct {
-	repo AccountRepository
+	repo     AccountRepository
+	userRepo UserRepository
+	rateRepo RateRepository
 }
 
 // NewAccountUseCase — конструктор юзкейса счетов.
-func NewAccountUseCase(repo AccountRepository) *AccountUseCase {
-	return &AccountUseCase{repo: repo}
+func NewAccountUseCase(repo AccountRepository, userRepo UserRepository, rateRepo RateRepository) *AccountUseCase {
+	return &AccountUseCase{repo: repo, userRepo: userRepo, rateRepo: rateRepo}
 }
 
-// GetAll — получить все счета пользователя.
-func (uc *AccountUseCase) GetAll(userID int) ([]entity.Account, error) {
-	return uc.repo.GetAll(userID)
+// GetAll — получить все счета пользователя, включая совместные.
+func (uc *AccountUseCase) GetAll(ctx context.Context, userID int) ([]entity.Account, error) {
+	return uc.repo.GetAll(ctx, userID)
 }
 
-// GetByID — получить счёт по ID (с проверкой принадлежности пользователю).
-func (uc *AccountUseCase) GetByID(id, userID int) (entity.Account, error) {
-	return uc.repo.GetByID(id, userID)
+// GetByID — получить счёт по ID (с проверкой доступа пользователя).
+func (uc *AccountUseCase) GetByID(ctx context.Context, id, userID int) (entity.Account, error) {
+	return uc.repo.GetByID(ctx, id, userID)
 }
 
-// Create — создать новый счёт.
-func (uc *AccountUseCase) Create(account entity.Account) (entity.Account, error) {
-	return uc.repo.Create(account)
+// Create — создать новый счёт. Валюта приводится к верхнему регистру и должна быть в таблице rates.
+func (uc *AccountUseCase) Create(ctx context.Context, account entity.Account) (entity.Account, error) {
+	account.Currency = strings.ToUpper(strings.TrimSpace(account.Currency))
+	if err := checkCurrency(ctx, uc.rateRepo, account.Currency); err != nil {
+		return entity.Account{}, err
+	}
+	if err := checkComment(account.Comment); err != nil {
+		return entity.Account{}, err
+	}
+	return uc.repo.Create(ctx, account)
+}
+
+// Delete — удалить счёт (транзакции удалятся каскадом). Только владельцем.
+func (uc *AccountUseCase) Delete(ctx context.Context, id, userID int) error {
+	if err := uc.requireOwner(ctx, id, userID); err != nil {
+		return err
+	}
+	return uc.repo.Delete(ctx, id, userID)
+}
+
+// Exists — проверить, что счёт существует и пользователь имеет к нему доступ.
+func (uc *AccountUseCase) Exists(ctx context.Context, id, userID int) (bool, error) {
+	return uc.repo.Exists(ctx, id, userID)
+}
+
+// GetRole — роль пользователя на счёте; entity.ErrAccountNotFound, если доступа нет.
+func (uc *AccountUseCase) GetRole(ctx context.Context, id, userID int) (string, error) {
+	return uc.repo.GetRole(ctx, id, userID)
+}
+
+// CanEdit — проверить, что пользователь может вести операции по счёту (владелец или редактор).
+// entity.ErrAccountNotFound, если доступа к счёту нет; ErrReadOnlyAccount — если доступ только на просмотр.
+func (uc *AccountUseCase) CanEdit(ctx context.Context, id, userID int) error {
+	return canEditAccount(ctx, uc.repo, id, userID)
+}
+
+// UpdateComment — обновить комментарий счёта. Только владельцем.
+func (uc *AccountUseCase) UpdateComment(ctx context.Context, id, userID int, comment string) error {
+	if err := checkComment(comment); err != nil {
+		return err
+	}
+	if err := uc.requireOwner(ctx, id, userID); err != nil {
+		return err
+	}
+	return uc.repo.UpdateComment(ctx, id, userID, comment)
+}
+
+// GetMembers — участники счёта; список видят все, у кого есть доступ к счёту.
+func (uc *AccountUseCase) GetMembers(ctx context.Context, id, userID int) ([]entity.AccountMember, error) {
+	if _, err := uc.repo.GetRole(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return uc.repo.GetMembers(ctx, id)
+}
+
+// SetMember — пригласить зарегистрированного пользователя по email на счёт с ролью role
+// (viewer или editor) или сменить роль участника. Только владельцем.
+func (uc *AccountUseCase) SetMember(ctx context.Context, id, ownerID int, email, role string) (entity.AccountMember, error) {
+	if role != entity.RoleViewer && role != entity.RoleEditor {
+		return entity.AccountMember{}, ErrInvalidRole
+	}
+	if err := uc.requireOwner(ctx, id, ownerID); err != nil {
+		return entity.AccountMember{}, err
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, strings.TrimSpace(email))
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return entity.AccountMember{}, entity.ErrUserNotFound.WithField("email", "Пользователь с таким email не найден")
+	}
+	if err != nil {
+		return entity.AccountMember{}, err
+	}
+	if user.ID == ownerID {
+		return entity.AccountMember{}, ErrInvalidMember
+	}
+	return uc.repo.SetMember(ctx, id, user.ID, role)
 }
 
-// Delete — удалить счёт (транзакции удалятся каскадом).
-func (uc *AccountUseCase) Delete(id, userID int) (int64, error) {
-	return uc.repo.Delete(id, userID)
+// RemoveMember — закрыть участнику memberID доступ к счёту.
+// Владелец может удалить любого участника, участник — только себя (выйти из счёта).
+func (uc *AccountUseCase) RemoveMember(ctx context.Context, id, userID, memberID int) error {
+	role, err := uc.repo.GetRole(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if role != entity.RoleOwner && memberID != userID {
+		return ErrNotAccountOwner
+	}
+	if role == entity.RoleOwner && memberID == userID {
+		return ErrInvalidMember
+	}
+	return uc.repo.RemoveMember(ctx, id, memberID)
 }
 
-// Exists — проверить существование счёта у пользователя.
-func (uc *AccountUseCase) Exists(id, userID int) (bool, error) {
-	return uc.repo.Exists(id, userID)
+// requireOwner — entity.ErrAccountNotFound, если доступа к счёту нет; ErrNotAccountOwner, если пользователь не владелец.
+func (uc *AccountUseCase) requireOwner(ctx context.Context, id, userID int) error {
+	role, err := uc.repo.GetRole(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if role != entity.RoleOwner {
+		return ErrNotAccountOwner
+	}
+	return nil
 }
 
-// UpdateComment — обновить комментарий счёта.
-func (uc *AccountUseCase) UpdateComment(id, userID int, comment string) error {
-	return uc.repo.UpdateComment(id, userID, comment)
+// canEditAccount — entity.ErrAccountNotFound, если доступа к счёту нет; ErrReadOnlyAccount, если пользователь — наблюдатель.
+func canEditAccount(ctx context.Context, repo AccountRepository, id, userID int) error {
+	role, err := repo.GetRole(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if role == entity.RoleViewer {
+		return ErrReadOnlyAccount
+	}
+	return nil
 }
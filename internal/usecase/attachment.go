@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"vue-calc/internal/entity"
+)
+
+// MaxAttachmentSize — максимальный размер одного вложения.
+const MaxAttachmentSize = 10 << 20
+
+// attachmentTypes — допустимые типы вложений. Тип определяется по содержимому файла,
+// а не по заголовку или расширению, присланным клиентом.
+var attachmentTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+}
+
+var (
+	// ErrAttachmentTooLarge — файл больше MaxAttachmentSize.
+	ErrAttachmentTooLarge = entity.NewError(entity.KindTooLarge, "attachment_too_large", "Файл больше 10 МБ")
+	// ErrAttachmentType — файл не изображение и не PDF.
+	ErrAttachmentType = entity.NewError(entity.KindUnsupported, "attachment_type", "Допустимы только изображения (JPEG, PNG, GIF, WebP) и PDF")
+)
+
+// FileStorage — хранилище содержимого вложений. Реализации — в пакете storage.
+type FileStorage interface {
+	Save(key string, r io.Reader) error
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}
+
+// AttachmentRepository — интерфейс репозитория вложений.
+type AttachmentRepository interface {
+	GetByTransaction(ctx context.Context, transactionID, accountID int) ([]entity.Attachment, error)
+	GetByID(ctx context.Context, id, transactionID, accountID int) (entity.Attachment, error)
+	Create(ctx context.Context, attachment entity.Attachment, accountID int) (entity.Attachment, error)
+	Delete(ctx context.Context, id, transactionID, accountID int) (entity.Attachment, error)
+}
+
+// AttachmentUseCase — бизнес-логика вложений операций (чеки, документы).
+type AttachmentUseCase struct {
+	repo    AttachmentRepository
+	storage FileStorage
+}
+
+// NewAttachmentUseCase — конструктор юзкейса вложений.
+func NewAttachmentUseCase(repo AttachmentRepository, storage FileStorage) *AttachmentUseCase {
+	return &AttachmentUseCase{repo: repo, storage: storage}
+}
+
+// GetByTransaction — вложения операции.
+func (uc *AttachmentUseCase) GetByTransaction(ctx context.Context, transactionID, accountID int) ([]entity.Attachment, error) {
+	return uc.repo.GetByTransaction(ctx, transactionID, accountID)
+}
+
+// Upload — сохранить файл как вложение операции.
+// Файл сначала пишется в хранилище, затем создаётся запись в БД; если запись не создалась,
+// файл удаляется.
+func (uc *AttachmentUseCase) Upload(ctx context.Context, transactionID, accountID int, fileName string, r io.Reader) (entity.Attachment, error) {
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return entity.Attachment{}, err
+	}
+	contentType := http.DetectContentType(head)
+	if !attachmentTypes[contentType] {
+		return entity.Attachment{}, ErrAttachmentType
+	}
+
+	key, err := randomToken(24)
+	if err != nil {
+		return entity.Attachment{}, err
+	}
+
+	counter := &sizeLimitReader{r: br, limit: MaxAttachmentSize}
+	if err := uc.storage.Save(key, counter); err != nil {
+		return entity.Attachment{}, err
+	}
+
+	attachment, err := uc.repo.Create(ctx, entity.Attachment{
+		TransactionID: transactionID,
+		FileName:      cleanFileName(fileName, contentType),
+		ContentType:   contentType,
+		Size:          counter.n,
+		StorageKey:    key,
+	}, accountID)
+	if err != nil {
+		deleteStoredFiles(uc.storage, key)
+		return entity.Attachment{}, err
+	}
+	return attachment, nil
+}
+
+// Open — вложение и его содержимое для скачивания. Закрыть reader должен вызывающий.
+func (uc *AttachmentUseCase) Open(ctx context.Context, id, transactionID, accountID int) (entity.Attachment, io.ReadCloser, error) {
+	attachment, err := uc.repo.GetByID(ctx, id, transactionID, accountID)
+	if err != nil {
+		return entity.Attachment{}, nil, err
+	}
+	content, err := uc.storage.Open(attachment.StorageKey)
+	if err != nil {
+		return entity.Attachment{}, nil, err
+	}
+	return attachment, content, nil
+}
+
+// Delete — удалить вложение вместе с файлом.
+func (uc *AttachmentUseCase) Delete(ctx context.Context, id, transactionID, accountID int) error {
+	attachment, err := uc.repo.Delete(ctx, id, transactionID, accountID)
+	if err != nil {
+		return err
+	}
+	deleteStoredFiles(uc.storage, attachment.StorageKey)
+	return nil
+}
+
+// deleteStoredFiles — удалить файлы вложений из хранилища. Записи в БД к этому моменту
+// уже удалены, поэтому ошибка только логируется: оставшийся файл никому не виден.
+func deleteStoredFiles(storage FileStorage, keys ...string) {
+	for _, key := range keys {
+		if err := storage.Delete(key); err != nil {
+			log.Println("Ошибка удаления файла вложения", key+":", err)
+		}
+	}
+}
+
+// sizeLimitReader — считает прочитанные байты и возвращает ErrAttachmentTooLarge,
+// как только их больше limit.
+type sizeLimitReader struct {
+	r     io.Reader
+	limit int64
+	n     int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n += int64(n)
+	if l.n > l.limit {
+		return n, ErrAttachmentTooLarge
+	}
+	return n, err
+}
+
+// cleanFileName — имя файла без пути и управляющих символов; пустое заменяется на «attachment».
+func cleanFileName(name, contentType string) string {
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+	name = strings.TrimSpace(strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' {
+			return -1
+		}
+		return r
+	}, name))
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+		if contentType == "application/pdf" {
+			name += ".pdf"
+		}
+	}
+	return name
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"strconv"
+
+	"vue-calc/internal/entity"
+)
+
+// ErrInvalidAuditFilter — неизвестный тип объекта или действие в фильтре журнала.
+var ErrInvalidAuditFilter = entity.NewError(entity.KindValidation, "invalid_audit_filter", "Неверный entity_type или action")
+
+// AuditRepository — интерфейс репозитория журнала изменений.
+type AuditRepository interface {
+	GetByUser(ctx context.Context, userID int, filter entity.AuditFilter) ([]entity.AuditEntry, error)
+}
+
+// AuditUseCase — чтение журнала изменений пользователя.
+type AuditUseCase struct {
+	repo AuditRepository
+}
+
+// NewAuditUseCase — конструктор юзкейса журнала изменений.
+func NewAuditUseCase(repo AuditRepository) *AuditUseCase {
+	return &AuditUseCase{repo: repo}
+}
+
+// Get — страница журнала пользователя, от новых записей к старым.
+// cursor — значение next_cursor из предыдущей страницы (пусто — первая страница).
+func (uc *AuditUseCase) Get(ctx context.Context, userID int, filter entity.AuditFilter, cursor string) (entity.AuditPage, error) {
+	switch filter.EntityType {
+	case "", entity.AuditAccount, entity.AuditTransaction, entity.AuditCategory:
+	default:
+		return entity.AuditPage{}, ErrInvalidAuditFilter
+	}
+	switch filter.Action {
+	case "", entity.AuditCreate, entity.AuditUpdate, entity.AuditDelete, entity.AuditRestore, entity.AuditPurge, entity.AuditMerge:
+	default:
+		return entity.AuditPage{}, ErrInvalidAuditFilter
+	}
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageLimit
+	}
+	if filter.Limit > maxPageLimit {
+		filter.Limit = maxPageLimit
+	}
+
+	if cursor != "" {
+		id, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || id <= 0 {
+			return entity.AuditPage{}, ErrInvalidCursor
+		}
+		filter.BeforeID = id
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница.
+	pageLimit := filter.Limit
+	filter.Limit++
+	items, err := uc.repo.GetByUser(ctx, userID, filter)
+	if err != nil {
+		return entity.AuditPage{}, err
+	}
+
+	page := entity.AuditPage{Items: items}
+	if len(items) > pageLimit {
+		page.Items = items[:pageLimit]
+		page.NextCursor = strconv.FormatInt(page.Items[pageLimit-1].ID, 10)
+	}
+	return page, nil
+}
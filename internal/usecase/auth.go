@@ -1,9 +1,18 @@
 package usecase
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"os"
+	"log"
+	"net/mail"
+	"net/url"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
@@ -11,52 +20,400 @@ import (
 	"vue-calc/internal/entity"
 )
 
+const (
+	// verifyEmailTTL — срок действия ссылки подтверждения email.
+	verifyEmailTTL = 48 * time.Hour
+	// resetPasswordTTL — срок действия ссылки сброса пароля.
+	resetPasswordTTL = time.Hour
+	// minPasswordLength — минимальная длина пароля в символах (как проверяет фронтенд).
+	minPasswordLength = 6
+	// maxPasswordBytes — bcrypt учитывает не больше 72 байт пароля, длиннее — ошибка.
+	maxPasswordBytes = 72
+)
+
+var (
+	// ErrInvalidCredentials — неверный email или пароль.
+	ErrInvalidCredentials = entity.NewError(entity.KindUnauthorized, "invalid_credentials", "Неверный email или пароль")
+	// ErrInvalidToken — токен не найден, истёк или его сессия отозвана.
+	ErrInvalidToken = entity.NewError(entity.KindUnauthorized, "invalid_token", "Невалидный или просроченный токен")
+	// ErrTokenReused — refresh-токен предъявлен повторно; сессия отозвана.
+	ErrTokenReused = entity.NewError(entity.KindUnauthorized, "token_reused", "Refresh-токен уже использован, сессия отозвана")
+	// ErrInvalidEmail — строка не похожа на адрес электронной почты.
+	ErrInvalidEmail = entity.NewValidationError("invalid_email", "email", "Неверный формат email")
+	// ErrPasswordTooShort — пароль короче minPasswordLength символов.
+	ErrPasswordTooShort = entity.NewValidationError("password_too_short", "password", "Пароль должен быть не менее 6 символов")
+	// ErrPasswordTooLong — пароль длиннее maxPasswordBytes байт.
+	ErrPasswordTooLong = entity.NewValidationError("password_too_long", "password", "Пароль должен быть не длиннее 72 байт")
+	// ErrEmailVerified — email пользователя уже подтверждён.
+	ErrEmailVerified = entity.NewError(entity.KindConflict, "email_verified", "Email уже подтверждён")
+)
+
 // UserRepository — интерфейс репозитория пользователей.
 type UserRepository interface {
-	Create(email, passwordHash string) (entity.User, error)
-	GetByEmail(email string) (entity.User, error)
+	Create(ctx context.Context, email, passwordHash string) (entity.User, error)
+	GetByEmail(ctx context.Context, email string) (entity.User, error)
+	GetByID(ctx context.Context, id int) (entity.User, error)
+	CreateToken(ctx context.Context, userID int, purpose, tokenHash string, expiresAt time.Time) error
+	VerifyEmail(ctx context.Context, tokenHash string) (int, error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error)
+	RecordLoginFailure(ctx context.Context, email, ip string) error
+}
+
+// SessionRepository — интерфейс репозитория сессий и refresh-токенов.
+type SessionRepository interface {
+	Create(ctx context.Context, sessionID string, userID int, tokenHash string, expiresAt time.Time) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (entity.RefreshToken, error)
+	Rotate(ctx context.Context, oldHash, newHash, sessionID string, expiresAt time.Time) (bool, error)
+	Revoke(ctx context.Context, sessionID string) error
+	IsActive(ctx context.Context, sessionID string, userID int) (bool, error)
+}
+
+// Mailer — отправитель писем пользователям. Реализации — в пакете mailer.
+type Mailer interface {
+	Send(to, subject, body string) error
+}
+
+// AuthOptions — ключ подписи и сроки жизни токенов, адрес фронтенда для ссылок в письмах.
+type AuthOptions struct {
+	Secret     []byte        // ключ подписи JWT
+	AccessTTL  time.Duration // время жизни access-токена
+	RefreshTTL time.Duration // время жизни refresh-токена; каждое обновление выдаёт новый токен на тот же срок
+	AppURL     string
 }
 
 // AuthUseCase — бизнес-логика аутентификации.
 type AuthUseCase struct {
-	repo UserRepository
+	repo     UserRepository
+	sessions SessionRepository
+	limiter  *Limiter
+	mailer   Mailer
+	opts     AuthOptions
 }
 
 // NewAuthUseCase — конструктор.
-func NewAuthUseCase(repo UserRepository) *AuthUseCase {
-	return &AuthUseCase{repo: repo}
+func NewAuthUseCase(repo UserRepository, sessions SessionRepository, limiter *Limiter, mailer Mailer, opts AuthOptions) *AuthUseCase {
+	opts.AppURL = strings.TrimRight(opts.AppURL, "/")
+	return &AuthUseCase{repo: repo, sessions: sessions, limiter: limiter, mailer: mailer, opts: opts}
 }
 
-// Register — регистрация нового пользователя.
-func (uc *AuthUseCase) Register(email, password string) (entity.User, error) {
+// Register — регистрация нового пользователя с IP-адреса ip.
+// Число регистраций с одного IP ограничено (*TooManyAttemptsError).
+// После создания на email отправляется ссылка подтверждения; если письмо не ушло,
+// регистрация всё равно успешна — ссылку можно запросить повторно.
+func (uc *AuthUseCase) Register(ctx context.Context, email, password, ip string) (entity.User, error) {
+	ipKey := "register:ip:" + ip
+	if err := uc.limiter.Check(ctx, ipKey); err != nil {
+		return entity.User{}, err
+	}
+	if err := uc.limiter.Add(ctx, ipKey, registerIPPolicy); err != nil {
+		return entity.User{}, err
+	}
+
+	email = normalizeEmail(email)
+	if !validEmail(email) {
+		return entity.User{}, ErrInvalidEmail
+	}
+	if err := checkPassword(password); err != nil {
+		return entity.User{}, err
+	}
+
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
 		return entity.User{}, err
 	}
-	return uc.repo.Create(email, string(hash))
+	user, err := uc.repo.Create(ctx, email, string(hash))
+	if err != nil {
+		return entity.User{}, err
+	}
+
+	if err := uc.sendVerification(ctx, user); err != nil {
+		log.Println("Ошибка отправки письма подтверждения для", user.Email+":", err)
+	}
+	return user, nil
+}
+
+// ResendVerification — повторно отправить ссылку подтверждения email.
+// Прежние ссылки перестают действовать.
+func (uc *AuthUseCase) ResendVerification(ctx context.Context, userID int) error {
+	user, err := uc.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerified {
+		return ErrEmailVerified
+	}
+	return uc.sendVerification(ctx, user)
+}
+
+// VerifyEmail — подтвердить email по токену из письма. Токен одноразовый.
+func (uc *AuthUseCase) VerifyEmail(ctx context.Context, token string) error {
+	_, err := uc.repo.VerifyEmail(ctx, hashToken(token))
+	return err
+}
+
+// RequestPasswordReset — отправить ссылку сброса пароля, если такой пользователь есть.
+// Для неизвестного email и при ошибке отправки тоже возвращается nil,
+// чтобы по ответу нельзя было узнать, зарегистрирован ли адрес.
+func (uc *AuthUseCase) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := uc.repo.GetByEmail(ctx, normalizeEmail(email))
+	if errors.Is(err, entity.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	token, err := uc.newUserToken(ctx, user.ID, entity.TokenResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+	body := "Чтобы задать новый пароль, откройте ссылку (действует 1 час):\n\n" +
+		uc.opts.AppURL + "/reset-password?token=" + url.QueryEscape(token) + "\n\n" +
+		"Если вы не запрашивали сброс пароля, просто проигнорируйте это письмо."
+	if err := uc.mailer.Send(user.Email, "Сброс пароля", body); err != nil {
+		log.Println("Ошибка отправки письма сброса пароля для", user.Email+":", err)
+	}
+	return nil
+}
+
+// ResetPassword — задать новый пароль по токену из письма.
+// Все сессии пользователя отзываются — войти придётся заново уже с новым паролем.
+func (uc *AuthUseCase) ResetPassword(ctx context.Context, token, password string) error {
+	if err := checkPassword(password); err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	_, err = uc.repo.ResetPassword(ctx, hashToken(token), string(hash))
+	return err
+}
+
+// Login — вход пользователя с IP-адреса ip: открывает новую сессию и возвращает пару токенов.
+// Неудачные входы записываются; после нескольких неудач подряд аккаунт и IP блокируются
+// на растущий срок (*TooManyAttemptsError), проверка пароля при этом не выполняется.
+func (uc *AuthUseCase) Login(ctx context.Context, email, password, ip string) (entity.TokenPair, error) {
+	email = normalizeEmail(email)
+	accountKey := "login:email:" + email
+	ipKey := "login:ip:" + ip
+	if err := uc.limiter.Check(ctx, accountKey, ipKey); err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	user, err := uc.repo.GetByEmail(ctx, email)
+	notFound := errors.Is(err, entity.ErrUserNotFound)
+	if err != nil && !notFound {
+		return entity.TokenPair{}, err
+	}
+	if notFound || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		if err := uc.loginFailed(ctx, email, ip, accountKey, ipKey); err != nil {
+			return entity.TokenPair{}, err
+		}
+		return entity.TokenPair{}, ErrInvalidCredentials
+	}
+	if err := uc.limiter.Reset(ctx, accountKey); err != nil {
+		log.Println("Ошибка сброса счётчика попыток входа:", err)
+	}
+
+	sessionID, err := randomToken(16)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+	refresh, err := randomToken(32)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+	if err := uc.sessions.Create(ctx, sessionID, user.ID, hashToken(refresh), time.Now().Add(uc.opts.RefreshTTL)); err != nil {
+		return entity.TokenPair{}, err
+	}
+
+	return uc.issue(user.ID, sessionID, refresh)
 }
 
-// Login — вход пользователя, возвращает JWT-токен.
-func (uc *AuthUseCase) Login(email, password string) (string, error) {
-	user, err := uc.repo.GetByEmail(email)
+// Refresh — обменять refresh-токен на новую пару токенов (ротация).
+// Повторное предъявление уже обменянного токена — признак кражи:
+// вся сессия отзывается, и войти заново придётся и владельцу, и злоумышленнику.
+func (uc *AuthUseCase) Refresh(ctx context.Context, refreshToken string) (entity.TokenPair, error) {
+	stored, err := uc.sessions.GetRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, entity.ErrRefreshTokenNotFound) {
+		return entity.TokenPair{}, ErrInvalidToken
+	}
 	if err != nil {
-		return "", errors.New("неверный email или пароль")
+		return entity.TokenPair{}, err
+	}
+	if stored.Revoked || time.Now().After(stored.ExpiresAt) {
+		return entity.TokenPair{}, ErrInvalidToken
+	}
+	if stored.Used {
+		return entity.TokenPair{}, uc.revokeReused(ctx, stored)
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)); err != nil {
-		return "", errors.New("неверный email или пароль")
+	refresh, err := randomToken(32)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+	ok, err := uc.sessions.Rotate(ctx, stored.Hash, hashToken(refresh), stored.SessionID, time.Now().Add(uc.opts.RefreshTTL))
+	if err != nil {
+		return entity.TokenPair{}, err
 	}
+	if !ok {
+		// Токен обменяли параллельно — это тоже повторное использование.
+		return entity.TokenPair{}, uc.revokeReused(ctx, stored)
+	}
+
+	return uc.issue(stored.UserID, stored.SessionID, refresh)
+}
 
+// Logout — отозвать сессию, к которой относится refresh-токен.
+// Неизвестный или уже отозванный токен не считается ошибкой.
+func (uc *AuthUseCase) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := uc.sessions.GetRefreshToken(ctx, hashToken(refreshToken))
+	if errors.Is(err, entity.ErrRefreshTokenNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return uc.sessions.Revoke(ctx, stored.SessionID)
+}
+
+// Authenticate — проверить access-токен и вернуть ID пользователя.
+// Токен отклоняется, если его сессия отозвана (выход или повторное использование refresh-токена).
+func (uc *AuthUseCase) Authenticate(ctx context.Context, accessToken string) (int, error) {
+	token, err := jwt.Parse(accessToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return uc.opts.Secret, nil
+	})
+	if err != nil || !token.Valid {
+		return 0, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	userIDFloat, ok := claims["user_id"].(float64)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+	sessionID, ok := claims["sid"].(string)
+	if !ok {
+		return 0, ErrInvalidToken
+	}
+
+	active, err := uc.sessions.IsActive(ctx, sessionID, int(userIDFloat))
+	if err != nil {
+		return 0, err
+	}
+	if !active {
+		return 0, ErrInvalidToken
+	}
+	return int(userIDFloat), nil
+}
+
+// issue — подписать access-токен сессии и собрать пару токенов.
+func (uc *AuthUseCase) issue(userID int, sessionID, refresh string) (entity.TokenPair, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"exp":     time.Now().Add(72 * time.Hour).Unix(),
+		"user_id": userID,
+		"sid":     sessionID,
+		"exp":     time.Now().Add(uc.opts.AccessTTL).Unix(),
 	})
 
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "vue-calc-default-secret"
+	access, err := token.SignedString(uc.opts.Secret)
+	if err != nil {
+		return entity.TokenPair{}, err
+	}
+	return entity.TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		ExpiresIn:    int(uc.opts.AccessTTL.Seconds()),
+	}, nil
+}
+
+// revokeReused — отозвать сессию повторно предъявленного refresh-токена.
+func (uc *AuthUseCase) revokeReused(ctx context.Context, stored entity.RefreshToken) error {
+	log.Println("Повторное использование refresh-токена, сессия отозвана:", stored.SessionID, "пользователь", stored.UserID)
+	if err := uc.sessions.Revoke(ctx, stored.SessionID); err != nil {
+		return err
+	}
+	return ErrTokenReused
+}
+
+// loginFailed — записать неудачный вход и учесть его в счётчиках аккаунта и IP.
+func (uc *AuthUseCase) loginFailed(ctx context.Context, email, ip, accountKey, ipKey string) error {
+	log.Println("Неудачный вход:", email, "с адреса", ip)
+	if err := uc.repo.RecordLoginFailure(ctx, email, ip); err != nil {
+		return err
+	}
+	if err := uc.limiter.Add(ctx, accountKey, loginAccountPolicy); err != nil {
+		return err
+	}
+	return uc.limiter.Add(ctx, ipKey, loginIPPolicy)
+}
+
+// sendVerification — выдать токен подтверждения email и отправить ссылку пользователю.
+func (uc *AuthUseCase) sendVerification(ctx context.Context, user entity.User) error {
+	token, err := uc.newUserToken(ctx, user.ID, entity.TokenVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+	body := "Подтвердите адрес электронной почты, открыв ссылку (действует 48 часов):\n\n" +
+		uc.opts.AppURL + "/verify-email?token=" + url.QueryEscape(token)
+	return uc.mailer.Send(user.Email, "Подтверждение email", body)
+}
+
+// newUserToken — создать одноразовый токен для письма; в БД сохраняется только его хэш.
+func (uc *AuthUseCase) newUserToken(ctx context.Context, userID int, purpose string, ttl time.Duration) (string, error) {
+	token, err := randomToken(32)
+	if err != nil {
+		return "", err
 	}
+	if err := uc.repo.CreateToken(ctx, userID, purpose, hashToken(token), time.Now().Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// normalizeEmail — email без пробелов по краям и в нижнем регистре: так он хранится,
+// ищется при входе и сбросе пароля и входит в ключ ограничения попыток.
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkPassword — пароль не короче minPasswordLength символов и не длиннее maxPasswordBytes байт.
+func checkPassword(password string) error {
+	if utf8.RuneCountInString(password) < minPasswordLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > maxPasswordBytes {
+		return ErrPasswordTooLong
+	}
+	return nil
+}
+
+// validEmail — адрес вида local@domain без имени и угловых скобок, не длиннее 254 символов.
+func validEmail(email string) bool {
+	if email == "" || len(email) > 254 {
+		return false
+	}
+	addr, err := mail.ParseAddress(email)
+	return err == nil && addr.Name == "" && addr.Address == email
+}
+
+// randomToken — случайная строка из n байт в base64url.
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
 
-	return token.SignedString([]byte(secret))
+// hashToken — SHA-256 хэш токена (refresh-токена или токена из письма) для хранения в БД.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,205 @@
+package usecase
+
+import (
+	"context"
+	"math"
+	"strings"
+	"time"
+
+	"vue-calc/internal/entity"
+)
+
+var (
+	// ErrInvalidBudget — лимит должен быть положительным.
+	ErrInvalidBudget = entity.NewValidationError("invalid_budget", "amount", "Лимит бюджета должен быть больше нуля")
+	// ErrInvalidPeriod — период должен быть в формате YYYY-MM.
+	ErrInvalidPeriod = entity.NewValidationError("invalid_period", "period", "Неверный period, ожидается YYYY-MM")
+)
+
+// BudgetRepository — интерфейс репозитория бюджетов.
+type BudgetRepository interface {
+	GetAll(ctx context.Context, userID int) ([]entity.Budget, error)
+	GetByID(ctx context.Context, id, userID int) (entity.Budget, error)
+	Create(ctx context.Context, budget entity.Budget) (entity.Budget, error)
+	Update(ctx context.Context, budget entity.Budget) (entity.Budget, error)
+	Delete(ctx context.Context, id, userID int) error
+}
+
+// BudgetUseCase — бизнес-логика месячных бюджетов по категориям.
+type BudgetUseCase struct {
+	repo         BudgetRepository
+	categoryRepo CategoryRepository
+	rateRepo     RateRepository
+	statsRepo    StatisticsRepository
+}
+
+// NewBudgetUseCase — конструктор юзкейса бюджетов.
+func NewBudgetUseCase(repo BudgetRepository, categoryRepo CategoryRepository, rateRepo RateRepository, statsRepo StatisticsRepository) *BudgetUseCase {
+	return &BudgetUseCase{repo: repo, categoryRepo: categoryRepo, rateRepo: rateRepo, statsRepo: statsRepo}
+}
+
+// GetAll — получить все бюджеты пользователя.
+func (uc *BudgetUseCase) GetAll(ctx context.Context, userID int) ([]entity.Budget, error) {
+	return uc.repo.GetAll(ctx, userID)
+}
+
+// Create — задать бюджет для категории пользователя.
+func (uc *BudgetUseCase) Create(ctx context.Context, budget entity.Budget) (entity.Budget, error) {
+	exists, err := uc.categoryRepo.Exists(ctx, budget.CategoryID, budget.UserID)
+	if err != nil {
+		return entity.Budget{}, err
+	}
+	if !exists {
+		return entity.Budget{}, entity.ErrCategoryNotFound
+	}
+
+	budgets, err := uc.repo.GetAll(ctx, budget.UserID)
+	if err != nil {
+		return entity.Budget{}, err
+	}
+	for _, b := range budgets {
+		if b.CategoryID == budget.CategoryID {
+			return entity.Budget{}, entity.ErrBudgetExists
+		}
+	}
+
+	if err := uc.validate(ctx, &budget); err != nil {
+		return entity.Budget{}, err
+	}
+	return uc.repo.Create(ctx, budget)
+}
+
+// Update — изменить лимит, валюту или перенос остатка.
+func (uc *BudgetUseCase) Update(ctx context.Context, budget entity.Budget) (entity.Budget, error) {
+	if err := uc.validate(ctx, &budget); err != nil {
+		return entity.Budget{}, err
+	}
+	if _, err := uc.repo.Update(ctx, budget); err != nil {
+		return entity.Budget{}, err
+	}
+	return uc.repo.GetByID(ctx, budget.ID, budget.UserID)
+}
+
+// Delete — удалить бюджет.
+func (uc *BudgetUseCase) Delete(ctx context.Context, id, userID int) error {
+	return uc.repo.Delete(ctx, id, userID)
+}
+
+// GetProgress — исполнение всех бюджетов пользователя за месяц period (YYYY-MM, пусто — текущий).
+// Расходы пересчитываются в валюту бюджета так же, как в статистике.
+// Для бюджетов с переносом остатка учитываются все месяцы с создания бюджета до period.
+func (uc *BudgetUseCase) GetProgress(ctx context.Context, userID int, period string) ([]entity.BudgetProgress, error) {
+	month, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+	from := month.Format(dateLayout)
+	to := month.AddDate(0, 1, -1).Format(dateLayout)
+
+	budgets, err := uc.repo.GetAll(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Расходы по категориям запрашиваем один раз на каждую валюту бюджетов.
+	spent := map[string]map[int]entity.Money{}
+	result := []entity.BudgetProgress{}
+	for _, b := range budgets {
+		if _, ok := spent[b.Currency]; !ok {
+			if spent[b.Currency], err = uc.expenseByCategory(ctx, userID, from, to, b.Currency); err != nil {
+				return nil, err
+			}
+		}
+
+		p := entity.BudgetProgress{
+			Budget:    b,
+			Period:    month.Format("2006-01"),
+			Available: b.Amount,
+			Spent:     spent[b.Currency][b.CategoryID].Round(b.Currency),
+		}
+
+		if b.Rollover {
+			if p.CarryOver, err = uc.carryOver(ctx, userID, b, month); err != nil {
+				return nil, err
+			}
+			p.Available += p.CarryOver
+		}
+
+		p.Remaining = p.Available - p.Spent
+		switch {
+		case p.Available > 0:
+			p.PercentUsed = math.Round(p.Spent.Float64()/p.Available.Float64()*1000) / 10
+		case p.Spent > 0:
+			p.PercentUsed = 100
+		}
+
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+// carryOver — накопленный остаток с месяца создания бюджета до месяца month (не включая его):
+// сумма лимитов за прошедшие месяцы минус всё потраченное за них.
+func (uc *BudgetUseCase) carryOver(ctx context.Context, userID int, b entity.Budget, month time.Time) (entity.Money, error) {
+	created, err := time.Parse(time.RFC3339Nano, b.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Date(created.Year(), created.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	months := (month.Year()-start.Year())*12 + int(month.Month()-start.Month())
+	if months <= 0 {
+		return 0, nil
+	}
+
+	spent, err := uc.expenseByCategory(ctx, userID, start.Format(dateLayout), month.AddDate(0, 0, -1).Format(dateLayout), b.Currency)
+	if err != nil {
+		return 0, err
+	}
+	return b.Amount*entity.Money(months) - spent[b.CategoryID].Round(b.Currency), nil
+}
+
+// expenseByCategory — расходы за период по категориям в валюте currency.
+func (uc *BudgetUseCase) expenseByCategory(ctx context.Context, userID int, from, to, currency string) (map[int]entity.Money, error) {
+	stats, err := uc.statsRepo.GetExpenseByCategory(ctx, userID, from, to, currency)
+	if err != nil {
+		return nil, err
+	}
+	byCategory := map[int]entity.Money{}
+	for _, s := range stats {
+		if s.CategoryID != nil {
+			byCategory[*s.CategoryID] = s.Total
+		}
+	}
+	return byCategory, nil
+}
+
+// validate — проверить лимит и валюту бюджета.
+func (uc *BudgetUseCase) validate(ctx context.Context, budget *entity.Budget) error {
+	budget.Currency = strings.ToUpper(strings.TrimSpace(budget.Currency))
+	if budget.Currency == "" {
+		budget.Currency = "USD"
+	}
+	if err := checkCurrency(ctx, uc.rateRepo, budget.Currency); err != nil {
+		return err
+	}
+
+	budget.Amount = budget.Amount.Round(budget.Currency)
+	if budget.Amount <= 0 {
+		return ErrInvalidBudget
+	}
+	return nil
+}
+
+// parsePeriod — первое число месяца YYYY-MM; пустая строка — текущий месяц.
+func parsePeriod(period string) (time.Time, error) {
+	if period == "" {
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC), nil
+	}
+	month, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, ErrInvalidPeriod
+	}
+	return month, nil
+}
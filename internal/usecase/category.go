@@ -1,12 +1,31 @@
 package usecase
 
-import "vue-calc/internal/entity"
+import (
+	"context"
+	"strings"
+
+	"vue-calc/internal/entity"
+)
+
+var (
+	// ErrCategoryName — название категории пустое.
+	ErrCategoryName = entity.NewValidationError("invalid_category_name", "name", "Название категории обязательно")
+	// ErrInvalidParent — родительская категория не найдена у пользователя.
+	ErrInvalidParent = entity.NewValidationError("invalid_parent", "parent_id", "Родительская категория не найдена")
+	// ErrCategoryCycle — категорию нельзя вложить в саму себя или в свою подкатегорию.
+	ErrCategoryCycle = entity.NewValidationError("category_cycle", "parent_id", "Категорию нельзя вложить в саму себя или в свою подкатегорию")
+	// ErrInvalidMerge — категорию нельзя слить с самой собой или со своей подкатегорией.
+	ErrInvalidMerge = entity.NewValidationError("invalid_merge", "target_id", "Категорию нельзя слить с самой собой или со своей подкатегорией")
+)
 
 // CategoryRepository — интерфейс репозитория категорий.
 type CategoryRepository interface {
-	GetAllByUserID(userID int) ([]entity.Category, error)
-	Create(category entity.Category) (entity.Category, error)
-	Delete(id, userID int) error
+	GetAllByUserID(ctx context.Context, userID int) ([]entity.Category, error)
+	Create(ctx context.Context, category entity.Category) (entity.Category, error)
+	Update(ctx context.Context, category entity.Category) (entity.Category, error)
+	Merge(ctx context.Context, sourceID, targetID, userID int) (int64, error)
+	Delete(ctx context.Context, id, userID int) error
+	Exists(ctx context.Context, id, userID int) (bool, error)
 }
 
 // CategoryUseCase — бизнес-логика для работы с категориями расходов.
@@ -20,16 +39,131 @@ func NewCategoryUseCase(repo CategoryRepository) *CategoryUseCase {
 }
 
 // GetAll — получить все категории пользователя.
-func (uc *CategoryUseCase) GetAll(userID int) ([]entity.Category, error) {
-	return uc.repo.GetAllByUserID(userID)
+func (uc *CategoryUseCase) GetAll(ctx context.Context, userID int) ([]entity.Category, error) {
+	return uc.repo.GetAllByUserID(ctx, userID)
+}
+
+// Create — создать новую категорию (при ParentID — подкатегорию).
+// Название должно быть уникальным у пользователя без учёта регистра.
+func (uc *CategoryUseCase) Create(ctx context.Context, category entity.Category) (entity.Category, error) {
+	categories, err := uc.repo.GetAllByUserID(ctx, category.UserID)
+	if err != nil {
+		return entity.Category{}, err
+	}
+	category.Name = strings.TrimSpace(category.Name)
+	if err := checkCategory(categories, category); err != nil {
+		return entity.Category{}, err
+	}
+	return uc.repo.Create(ctx, category)
+}
+
+// CategoryChanges — изменения категории в Update; незаданные поля остаются прежними.
+type CategoryChanges struct {
+	// Name — новое название (nil — не менять).
+	Name *string
+	// SetParent — менять ли родителя; ParentID — новый родитель (nil — сделать корневой).
+	SetParent bool
+	ParentID  *int
+}
+
+// Update — переименовать категорию и/или перенести её под другого родителя
+// вместе со всеми подкатегориями. Операции категории остаются при ней.
+func (uc *CategoryUseCase) Update(ctx context.Context, id, userID int, changes CategoryChanges) (entity.Category, error) {
+	categories, err := uc.repo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return entity.Category{}, err
+	}
+	current := findCategory(categories, id)
+	if current == nil {
+		return entity.Category{}, entity.ErrCategoryNotFound
+	}
+
+	category := *current
+	if changes.Name != nil {
+		category.Name = strings.TrimSpace(*changes.Name)
+	}
+	if changes.SetParent {
+		category.ParentID = changes.ParentID
+	}
+	if err := checkCategory(categories, category); err != nil {
+		return entity.Category{}, err
+	}
+	return uc.repo.Update(ctx, category)
+}
+
+// Merge — слить категорию sourceID в targetID: операции, правила, бюджет и подкатегории
+// источника переходят к цели, источник удаляется (его можно восстановить из корзины,
+// но операции останутся в цели). Возвращает цель и число перенесённых операций.
+func (uc *CategoryUseCase) Merge(ctx context.Context, sourceID, targetID, userID int) (entity.Category, int64, error) {
+	categories, err := uc.repo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return entity.Category{}, 0, err
+	}
+	target := findCategory(categories, targetID)
+	if findCategory(categories, sourceID) == nil || target == nil {
+		return entity.Category{}, 0, entity.ErrCategoryNotFound
+	}
+	if sourceID == targetID || isDescendant(categories, targetID, sourceID) {
+		return entity.Category{}, 0, ErrInvalidMerge
+	}
+
+	moved, err := uc.repo.Merge(ctx, sourceID, targetID, userID)
+	if err != nil {
+		return entity.Category{}, 0, err
+	}
+	return *target, moved, nil
+}
+
+// Delete — удалить категорию. Её подкатегории показываются корневыми, пока родитель в корзине.
+func (uc *CategoryUseCase) Delete(ctx context.Context, id, userID int) error {
+	return uc.repo.Delete(ctx, id, userID)
+}
+
+// checkCategory — проверить уникальность названия и родителя категории среди категорий пользователя.
+// category.ID == 0 — новая категория.
+func checkCategory(categories []entity.Category, category entity.Category) error {
+	if category.Name == "" {
+		return ErrCategoryName
+	}
+	for _, c := range categories {
+		if c.ID != category.ID && strings.EqualFold(c.Name, category.Name) {
+			return entity.ErrCategoryExists
+		}
+	}
+
+	if category.ParentID == nil {
+		return nil
+	}
+	if findCategory(categories, *category.ParentID) == nil {
+		return ErrInvalidParent
+	}
+	if category.ID != 0 && (*category.ParentID == category.ID || isDescendant(categories, *category.ParentID, category.ID)) {
+		return ErrCategoryCycle
+	}
+	return nil
 }
 
-// Create — создать новую категорию.
-func (uc *CategoryUseCase) Create(category entity.Category) (entity.Category, error) {
-	return uc.repo.Create(category)
+// findCategory — категория с ID id или nil.
+func findCategory(categories []entity.Category, id int) *entity.Category {
+	for i := range categories {
+		if categories[i].ID == id {
+			return &categories[i]
+		}
+	}
+	return nil
 }
 
-// Delete — удалить категорию.
-func (uc *CategoryUseCase) Delete(id, userID int) error {
-	return uc.repo.Delete(id, userID)
+// isDescendant — категория id вложена (на любой глубине) в категорию ancestorID.
+func isDescendant(categories []entity.Category, id, ancestorID int) bool {
+	parents := make(map[int]*int, len(categories))
+	for _, c := range categories {
+		parents[c.ID] = c.ParentID
+	}
+	// Ограничение числа шагов защищает от зацикленных данных.
+	for p, steps := parents[id], 0; p != nil && steps <= len(categories); p, steps = parents[*p], steps+1 {
+		if *p == ancestorID {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+
+	"vue-calc/internal/entity"
+)
+
+// TableWriter — табличный вывод выгрузки (CSV, XLSX, JSON). Реализации — в пакете export.
+type TableWriter interface {
+	Sheet(name string, columns []string) error
+	Row(values ...interface{}) error
+	Close() error
+}
+
+// TransactionExportRepository — потоковое чтение операций пользователя для выгрузки.
+type TransactionExportRepository interface {
+	ForEachByUser(ctx context.Context, userID int, filter entity.ExportFilter, fn func(entity.ExportRow) error) error
+}
+
+// ExportUseCase — выгрузка операций и статистики в табличные форматы.
+type ExportUseCase struct {
+	txRepo  TransactionExportRepository
+	statsUC *StatisticsUseCase
+}
+
+// NewExportUseCase — конструктор юзкейса выгрузки.
+func NewExportUseCase(txRepo TransactionExportRepository, statsUC *StatisticsUseCase) *ExportUseCase {
+	return &ExportUseCase{txRepo: txRepo, statsUC: statsUC}
+}
+
+// Transactions — выгрузить операции пользователя построчно, не загружая их все в память.
+// В w ничего не пишется, пока запрос к БД не начал возвращать строки: ошибку запроса
+// вызывающий ещё может отдать вместо файла.
+func (uc *ExportUseCase) Transactions(ctx context.Context, userID int, filter entity.ExportFilter, w TableWriter) error {
+	columns := []string{"id", "date", "account_id", "account", "currency", "amount", "category", "tags", "comment", "transfer_id"}
+	started := false
+	err := uc.txRepo.ForEachByUser(ctx, userID, filter, func(row entity.ExportRow) error {
+		if !started {
+			started = true
+			if err := w.Sheet("transactions", columns); err != nil {
+				return err
+			}
+		}
+		return w.Row(row.ID, row.CreatedAt, row.AccountID, row.AccountComment, row.Currency,
+			row.Amount, row.Category, strings.Join(row.Tags, ","), row.Comment, row.TransferID)
+	})
+	if err != nil {
+		return err
+	}
+	if !started {
+		if err := w.Sheet("transactions", columns); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// Statistics — выгрузить агрегаты /api/statistics: итоги, доходы и расходы по категориям, по дням.
+// Статистика считается до записи в w, поэтому при ошибке w остаётся пустым.
+func (uc *ExportUseCase) Statistics(ctx context.Context, userID int, from, to string, accountID *int, currency string, w TableWriter) error {
+	stats, err := uc.statsUC.GetStatistics(ctx, userID, entity.StatisticsFilter{From: from, To: to, AccountID: accountID, Currency: currency})
+	if err != nil {
+		return err
+	}
+
+	if err := w.Sheet("summary", []string{"from", "to", "currency", "total_income", "total_expense"}); err != nil {
+		return err
+	}
+	if err := w.Row(from, to, stats.Currency, stats.TotalIncome, stats.TotalExpense); err != nil {
+		return err
+	}
+
+	categorySheets := []struct {
+		name  string
+		stats []entity.CategoryStat
+	}{
+		{"income_by_category", stats.IncomeByCategory},
+		{"expense_by_category", stats.ExpenseByCategory},
+	}
+	for _, sheet := range categorySheets {
+		if err := w.Sheet(sheet.name, []string{"category_id", "category", "total", "count"}); err != nil {
+			return err
+		}
+		for _, s := range sheet.stats {
+			if err := w.Row(s.CategoryID, s.CategoryName, s.Total, s.Count); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := w.Sheet("daily", []string{"date", "income", "expense"}); err != nil {
+		return err
+	}
+	for _, d := range stats.DailyStats {
+		if err := w.Row(d.Date, d.Income, d.Expense); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
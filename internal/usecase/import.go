@@ -0,0 +1,263 @@
+package usecase
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"vue-calc/internal/entity"
+)
+
+var (
+	// ErrInvalidImport — неверные настройки импорта или нечитаемый CSV.
+	ErrInvalidImport = entity.NewError(entity.KindValidation, "invalid_import", "Неверные настройки импорта")
+	// ErrImportRows — в выписке есть строки с ошибками, ничего не импортировано.
+	ErrImportRows = entity.NewError(entity.KindValidation, "import_rows", "В выписке есть строки с ошибками")
+)
+
+// dateFormatTokens — перевод привычных обозначений формата даты в layout Go.
+var dateFormatTokens = strings.NewReplacer(
+	"YYYY", "2006", "YY", "06", "MM", "01", "DD", "02",
+	"HH", "15", "mm", "04", "ss", "05",
+)
+
+// ImportUseCase — импорт банковских выписок в формате CSV.
+type ImportUseCase struct {
+	txUC         *TransactionUseCase
+	categoryRepo CategoryRepository
+}
+
+// NewImportUseCase — конструктор юзкейса импорта.
+func NewImportUseCase(txUC *TransactionUseCase, categoryRepo CategoryRepository) *ImportUseCase {
+	return &ImportUseCase{txUC: txUC, categoryRepo: categoryRepo}
+}
+
+// Import разбирает CSV и сохраняет все строки одной пачкой через TransactionUseCase.CreateBatch.
+// В режиме DryRun ничего не сохраняется — возвращается предпросмотр с ошибками по строкам,
+// включая ошибки категорий и проверок суммы (TransactionUseCase.PrepareBatch).
+// Если хотя бы одна строка не прошла проверку, не сохраняется ничего (ErrImportRows).
+func (uc *ImportUseCase) Import(ctx context.Context, accountID, userID int, r io.Reader, opts entity.ImportOptions) (entity.ImportResult, error) {
+	result := entity.ImportResult{DryRun: opts.DryRun, Rows: []entity.ImportRow{}}
+
+	if opts.DateColumn == "" || opts.AmountColumn == "" {
+		return result, ErrInvalidImport.WithDetail("укажите колонки даты и суммы")
+	}
+	if opts.Delimiter == 0 {
+		opts.Delimiter = ','
+	}
+	if opts.DecimalSeparator == "" {
+		opts.DecimalSeparator = "."
+	}
+	if opts.DecimalSeparator != "." && opts.DecimalSeparator != "," {
+		return result, ErrInvalidImport.WithDetail("десятичный разделитель должен быть точкой или запятой")
+	}
+	layout := "2006-01-02"
+	if opts.DateFormat != "" {
+		layout = dateFormatTokens.Replace(opts.DateFormat)
+	}
+
+	reader := csv.NewReader(r)
+	reader.Comma = opts.Delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return result, ErrInvalidImport.WithDetail(err.Error())
+	}
+
+	var header []string
+	firstLine := 1
+	if opts.HasHeader && len(records) > 0 {
+		header, records = records[0], records[1:]
+		firstLine = 2
+	}
+
+	dateCol, err := columnIndex(header, opts.DateColumn)
+	if err != nil {
+		return result, err
+	}
+	amountCol, err := columnIndex(header, opts.AmountColumn)
+	if err != nil {
+		return result, err
+	}
+	commentCol, categoryCol := -1, -1
+	if opts.CommentColumn != "" {
+		if commentCol, err = columnIndex(header, opts.CommentColumn); err != nil {
+			return result, err
+		}
+	}
+	if opts.CategoryColumn != "" {
+		if categoryCol, err = columnIndex(header, opts.CategoryColumn); err != nil {
+			return result, err
+		}
+	}
+
+	for i, record := range records {
+		row := entity.ImportRow{Line: firstLine + i}
+		if err := parseImportRow(&row, record, dateCol, amountCol, commentCol, categoryCol, layout, opts.DecimalSeparator); err != nil {
+			row.Error = err.Error()
+			result.Errors++
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	// Категории и суммы проверяются и в предпросмотре: ошибки попадают в строки выписки,
+	// а не всплывают только при настоящем импорте.
+	categories, newCategories, err := uc.resolveCategories(ctx, userID, &result)
+	if err != nil {
+		return result, err
+	}
+
+	transactions := make([]entity.Transaction, 0, len(result.Rows))
+	rows := make([]*entity.ImportRow, 0, len(result.Rows))
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		if row.Error != "" {
+			continue
+		}
+		t := entity.Transaction{
+			AccountID: accountID,
+			Amount:    row.Amount,
+			Comment:   row.Comment,
+			CreatedAt: row.Date,
+			CreatedBy: &userID,
+		}
+		name := strings.TrimSpace(row.Category)
+		if id, ok := categories[strings.ToLower(name)]; ok {
+			t.CategoryID = &id
+		} else if name != "" {
+			// Новая категория создаётся вместе с операциями и связывается с ними по названию.
+			t.Category = name
+		}
+		transactions = append(transactions, t)
+		rows = append(rows, row)
+	}
+
+	// Те же проверки, что при сохранении: сумма в точности валюты счёта, категория и т. д.
+	invalid, err := uc.txUC.PrepareBatch(ctx, transactions)
+	if err != nil {
+		return result, err
+	}
+	for i, err := range invalid {
+		if err != nil {
+			rows[i].Error = err.Error()
+			result.Errors++
+		}
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	if result.Errors > 0 {
+		return result, ErrImportRows
+	}
+
+	created, err := uc.txUC.CreateBatch(ctx, transactions, newCategories)
+	if err != nil {
+		return result, err
+	}
+	result.Imported = len(created)
+	return result, nil
+}
+
+// resolveCategories — сопоставить названия категорий из выписки с категориями пользователя
+// (без учёта регистра). Возвращает ID найденных категорий по названию в нижнем регистре
+// и проверенные недостающие категории — их создаёт CreateBatch вместе с операциями.
+// Строки с недопустимым названием новой категории помечаются ошибкой в result.
+func (uc *ImportUseCase) resolveCategories(ctx context.Context, userID int, result *entity.ImportResult) (map[string]int, []entity.Category, error) {
+	existing, err := uc.categoryRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	ids := map[string]int{}
+	for _, c := range existing {
+		ids[strings.ToLower(c.Name)] = c.ID
+	}
+
+	var missing []entity.Category
+	checked := map[string]error{}
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		// Название проверяется так же, как в CategoryUseCase.Create.
+		name := strings.TrimSpace(row.Category)
+		key := strings.ToLower(name)
+		if row.Error != "" || name == "" {
+			continue
+		}
+		if _, ok := ids[key]; ok {
+			continue
+		}
+		err, seen := checked[key]
+		if !seen {
+			category := entity.Category{UserID: userID, Name: name}
+			err = checkCategory(existing, category)
+			checked[key] = err
+			if err == nil {
+				missing = append(missing, category)
+			}
+		}
+		if err != nil {
+			row.Error = err.Error()
+			result.Errors++
+		}
+	}
+	return ids, missing, nil
+}
+
+// columnIndex — номер колонки по имени из заголовка или по числу (с нуля).
+func columnIndex(header []string, column string) (int, error) {
+	for i, name := range header {
+		if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(column)) {
+			return i, nil
+		}
+	}
+	if i, err := strconv.Atoi(column); err == nil && i >= 0 {
+		return i, nil
+	}
+	return 0, ErrInvalidImport.WithDetail(fmt.Sprintf("колонка %q не найдена", column))
+}
+
+// parseImportRow — разобрать одну строку CSV по настройкам импорта.
+func parseImportRow(row *entity.ImportRow, record []string, dateCol, amountCol, commentCol, categoryCol int, layout, decimalSep string) error {
+	field := func(i int) string {
+		if i < 0 || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	row.Comment = field(commentCol)
+	row.Category = field(categoryCol)
+	if checkComment(row.Comment) != nil {
+		return fmt.Errorf("комментарий длиннее %d символов", maxCommentLength)
+	}
+
+	date, err := time.Parse(layout, field(dateCol))
+	if err != nil {
+		return fmt.Errorf("неверная дата %q", field(dateCol))
+	}
+	if checkDateRange(date, time.Now()) != nil {
+		return fmt.Errorf("дата %q вне допустимого диапазона", field(dateCol))
+	}
+	row.Date = date.Format("2006-01-02T15:04:05")
+
+	// Убираем пробелы-разделители тысяч и приводим десятичный разделитель к точке.
+	raw := strings.NewReplacer(" ", "", "\u00a0", "", "'", "").Replace(field(amountCol))
+	if decimalSep == "," {
+		raw = strings.ReplaceAll(raw, ".", "")
+		raw = strings.ReplaceAll(raw, ",", ".")
+	} else {
+		raw = strings.ReplaceAll(raw, ",", "")
+	}
+	amount, err := entity.ParseMoney(raw)
+	if err != nil || checkAmount(amount) != nil {
+		return fmt.Errorf("неверная сумма %q", field(amountCol))
+	}
+	row.Amount = amount
+	return nil
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AttemptStore — хранилище счётчиков попыток входа и регистрации.
+// Реализации: limiter.Memory (в памяти процесса) и postgres.AttemptRepo (общее для нескольких реплик).
+type AttemptStore interface {
+	// LockedUntil — до какого момента ключ заблокирован (нулевое время — не заблокирован).
+	LockedUntil(ctx context.Context, key string, now time.Time) (time.Time, error)
+	// Incr — атомарно увеличить счётчик ключа и вернуть новое значение. Если последняя попытка
+	// была раньше idleSince, счёт начинается заново.
+	Incr(ctx context.Context, key string, now, idleSince time.Time) (int, error)
+	// Lock — заблокировать ключ до until; более поздняя уже стоящая блокировка не сокращается.
+	Lock(ctx context.Context, key string, until time.Time) error
+	// Reset — сбросить счётчик и блокировку ключа.
+	Reset(ctx context.Context, key string) error
+}
+
+// TooManyAttemptsError — слишком много попыток; повторить можно через RetryAfter.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("слишком много попыток, повторите через %s", e.RetryAfter.Round(time.Second))
+}
+
+// AttemptPolicy — правило блокировки: после Free попыток каждая следующая блокирует ключ
+// на Base, 2·Base, 4·Base… но не больше Max. Счётчик забывается, если попыток не было Idle.
+type AttemptPolicy struct {
+	Free int
+	Base time.Duration
+	Max  time.Duration
+	Idle time.Duration
+}
+
+// lockout — длительность блокировки после n-й попытки (0 — без блокировки).
+func (p AttemptPolicy) lockout(n int) time.Duration {
+	if n <= p.Free {
+		return 0
+	}
+	d := p.Base
+	for i := p.Free + 1; i < n && d < p.Max; i++ {
+		d *= 2
+	}
+	return min(d, p.Max)
+}
+
+var (
+	// loginAccountPolicy — неудачные входы в один аккаунт (с любых адресов).
+	loginAccountPolicy = AttemptPolicy{Free: 5, Base: time.Minute, Max: time.Hour, Idle: 24 * time.Hour}
+	// loginIPPolicy — неудачные входы с одного IP (в любые аккаунты): мягче, за одним адресом
+	// может быть много пользователей.
+	loginIPPolicy = AttemptPolicy{Free: 20, Base: time.Minute, Max: time.Hour, Idle: 24 * time.Hour}
+	// registerIPPolicy — все попытки регистрации с одного IP.
+	registerIPPolicy = AttemptPolicy{Free: 10, Base: time.Minute, Max: time.Hour, Idle: time.Hour}
+)
+
+// Limiter — ограничение частоты попыток по ключам (IP, аккаунт) с растущей блокировкой.
+type Limiter struct {
+	store AttemptStore
+	now   func() time.Time
+}
+
+// NewLimiter — конструктор ограничителя попыток.
+func NewLimiter(store AttemptStore) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Check — *TooManyAttemptsError, если хотя бы один ключ сейчас заблокирован.
+func (l *Limiter) Check(ctx context.Context, keys ...string) error {
+	now := l.now()
+	var retry time.Duration
+	for _, key := range keys {
+		until, err := l.store.LockedUntil(ctx, key, now)
+		if err != nil {
+			return err
+		}
+		retry = max(retry, until.Sub(now))
+	}
+	if retry > 0 {
+		return &TooManyAttemptsError{RetryAfter: retry}
+	}
+	return nil
+}
+
+// Add — учесть попытку по ключу и заблокировать его, если попыток больше, чем допускает policy.
+func (l *Limiter) Add(ctx context.Context, key string, policy AttemptPolicy) error {
+	now := l.now()
+	n, err := l.store.Incr(ctx, key, now, now.Add(-policy.Idle))
+	if err != nil {
+		return err
+	}
+	if d := policy.lockout(n); d > 0 {
+		return l.store.Lock(ctx, key, now.Add(d))
+	}
+	return nil
+}
+
+// Reset — забыть попытки по ключу (после успешного входа).
+func (l *Limiter) Reset(ctx context.Context, key string) error {
+	return l.store.Reset(ctx, key)
+}
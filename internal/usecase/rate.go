@@ -1,7 +1,13 @@
 package usecase
 
 import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"vue-calc/internal/entity"
@@ -9,14 +15,23 @@ import (
 
 // RateRepository — интерфейс для работы с курсами валют в БД.
 type RateRepository interface {
-	GetAll() ([]entity.Rate, error)
-	Upsert(currency string, rateToUSD float64) error
+	GetAll(ctx context.Context) ([]entity.Rate, error)
+	GetByCurrency(ctx context.Context, currency string) (entity.Rate, error)
+	Upsert(ctx context.Context, currency string, rateToUSD float64) error
+	UpsertHistory(ctx context.Context, currency, date string, rateToUSD float64) error
+	GetHistory(ctx context.Context, currency, from, to string) ([]entity.RateHistory, error)
 }
 
 // RateFetcher — интерфейс для получения курсов из внешнего API.
 // Отделяем HTTP-клиент от бизнес-логики, чтобы usecase не зависел от конкретного API.
 type RateFetcher interface {
-	FetchRates() (*entity.ExchangeRateResponse, error)
+	FetchRates(ctx context.Context) (*entity.ExchangeRateResponse, error)
+}
+
+// RateHealthReporter — необязательный интерфейс fetcher'а с несколькими источниками:
+// сообщает состояние каждого источника курсов.
+type RateHealthReporter interface {
+	Health() []entity.ProviderHealth
 }
 
 // RateUseCase — бизнес-логика для работы с курсами валют.
@@ -31,14 +46,70 @@ func NewRateUseCase(repo RateRepository, fetcher RateFetcher) *RateUseCase {
 }
 
 // GetAll возвращает все курсы валют из БД.
-func (uc *RateUseCase) GetAll() ([]entity.Rate, error) {
-	return uc.repo.GetAll()
+func (uc *RateUseCase) GetAll(ctx context.Context) ([]entity.Rate, error) {
+	return uc.repo.GetAll(ctx)
+}
+
+// ProvidersHealth возвращает состояние источников курсов (пусто, если fetcher его не сообщает).
+func (uc *RateUseCase) ProvidersHealth() []entity.ProviderHealth {
+	if reporter, ok := uc.fetcher.(RateHealthReporter); ok {
+		return reporter.Health()
+	}
+	return []entity.ProviderHealth{}
+}
+
+// GetHistory возвращает историю курсов валюты за период.
+func (uc *RateUseCase) GetHistory(ctx context.Context, currency, from, to string) ([]entity.RateHistory, error) {
+	return uc.repo.GetHistory(ctx, strings.ToUpper(currency), from, to)
+}
+
+// ImportHistory загружает прошлые курсы из CSV с колонками date,currency,rate
+// (date — YYYY-MM-DD, первая строка может быть заголовком).
+// Если perUSD — rate означает «сколько единиц валюты за 1 USD», как в ответе API;
+// иначе это уже курс к USD (rate_to_usd). Возвращает число загруженных строк.
+func (uc *RateUseCase) ImportHistory(ctx context.Context, r io.Reader, perUSD bool) (int, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 3
+	reader.TrimLeadingSpace = true
+
+	imported := 0
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return imported, err
+		}
+
+		date, currency := strings.TrimSpace(record[0]), strings.ToUpper(strings.TrimSpace(record[1]))
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			if line == 1 {
+				continue // заголовок
+			}
+			return imported, fmt.Errorf("строка %d: неверная дата %q", line, date)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if err != nil || rate <= 0 {
+			return imported, fmt.Errorf("строка %d: неверный курс %q", line, record[2])
+		}
+		if perUSD {
+			rate = 1.0 / rate
+		}
+
+		if err := uc.repo.UpsertHistory(ctx, currency, date, rate); err != nil {
+			return imported, fmt.Errorf("строка %d: %w", line, err)
+		}
+		imported++
+	}
+	return imported, nil
 }
 
 // SaveRates получает курсы из внешнего API и сохраняет их в БД.
 // Для каждой валюты вычисляет курс к USD (1 / rate) и делает upsert.
-func (uc *RateUseCase) SaveRates() {
-	rateResponse, err := uc.fetcher.FetchRates()
+func (uc *RateUseCase) SaveRates(ctx context.Context) {
+	rateResponse, err := uc.fetcher.FetchRates(ctx)
 	if err != nil {
 		log.Println("Ошибка получения курсов:", err)
 		return
@@ -55,7 +126,11 @@ func (uc *RateUseCase) SaveRates() {
 			rateToUSD = 1.0 / rate
 		}
 
-		if err := uc.repo.Upsert(currency, rateToUSD); err != nil {
+		if err := uc.repo.Upsert(ctx, currency, rateToUSD); err != nil {
+			if ctx.Err() != nil {
+				log.Println("Обновление курсов прервано:", ctx.Err())
+				return
+			}
 			log.Println("Ошибка сохранения курса для", currency, ":", err)
 		}
 	}
@@ -63,15 +138,20 @@ func (uc *RateUseCase) SaveRates() {
 	log.Println("Курсы валют обновлены успешно!")
 }
 
-// StartUpdater запускает фоновое обновление курсов каждый час.
-// Первый запрос выполняется сразу, далее — по таймеру.
-func (uc *RateUseCase) StartUpdater() {
-	uc.SaveRates()
-	ticker := time.NewTicker(1 * time.Hour)
+// RunUpdater обновляет курсы каждые interval, пока не отменён ctx.
+// Первый запрос выполняется сразу, далее — по таймеру. Возвращается после отмены ctx,
+// дождавшись завершения текущего обновления.
+func (uc *RateUseCase) RunUpdater(ctx context.Context, interval time.Duration) {
+	uc.SaveRates(ctx)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	go func() {
-		for range ticker.C {
-			uc.SaveRates()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			uc.SaveRates(ctx)
 		}
-	}()
+	}
 }
@@ -0,0 +1,312 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"vue-calc/internal/entity"
+)
+
+// dateLayout — формат дат правил повторяющихся операций.
+const dateLayout = "2006-01-02"
+
+var (
+	// ErrInvalidSchedule — неверная периодичность, день месяца или даты правила.
+	ErrInvalidSchedule = entity.NewError(entity.KindValidation, "invalid_schedule", "Неверное расписание: проверьте frequency, day_of_month и даты")
+	// ErrNotScheduled — в эту дату правило не срабатывает или дата уже обработана.
+	ErrNotScheduled = entity.NewValidationError("not_scheduled", "date", "В эту дату правило не срабатывает или она уже обработана")
+)
+
+// RecurringRepository — интерфейс репозитория повторяющихся операций.
+type RecurringRepository interface {
+	GetAll(ctx context.Context, userID int) ([]entity.RecurringRule, error)
+	GetByID(ctx context.Context, id, userID int) (entity.RecurringRule, error)
+	Create(ctx context.Context, rule entity.RecurringRule) (entity.RecurringRule, error)
+	Update(ctx context.Context, rule entity.RecurringRule) (entity.RecurringRule, error)
+	Delete(ctx context.Context, id, userID int) error
+	Skip(ctx context.Context, id int, date string) error
+	GetDue(ctx context.Context, date string) ([]entity.RecurringRule, error)
+	Materialize(ctx context.Context, rule entity.RecurringRule, nextDate string) (bool, error)
+}
+
+// RecurringUseCase — бизнес-логика повторяющихся операций и их фоновый планировщик.
+type RecurringUseCase struct {
+	repo         RecurringRepository
+	accountRepo  AccountRepository
+	categoryRepo CategoryRepository
+}
+
+// NewRecurringUseCase — конструктор юзкейса повторяющихся операций.
+func NewRecurringUseCase(repo RecurringRepository, accountRepo AccountRepository, categoryRepo CategoryRepository) *RecurringUseCase {
+	return &RecurringUseCase{repo: repo, accountRepo: accountRepo, categoryRepo: categoryRepo}
+}
+
+// GetAll — получить все правила пользователя.
+func (uc *RecurringUseCase) GetAll(ctx context.Context, userID int) ([]entity.RecurringRule, error) {
+	return uc.repo.GetAll(ctx, userID)
+}
+
+// GetByID — получить правило по ID.
+func (uc *RecurringUseCase) GetByID(ctx context.Context, id, userID int) (entity.RecurringRule, error) {
+	return uc.repo.GetByID(ctx, id, userID)
+}
+
+// Create — создать правило. Первое срабатывание — ближайшая по расписанию дата,
+// не раньше start_date; если start_date в прошлом, пропущенные даты будут созданы планировщиком.
+func (uc *RecurringUseCase) Create(ctx context.Context, rule entity.RecurringRule) (entity.RecurringRule, error) {
+	if err := uc.prepare(ctx, &rule); err != nil {
+		return entity.RecurringRule{}, err
+	}
+	start, _ := time.Parse(dateLayout, rule.StartDate)
+	rule.NextDate = firstOccurrence(rule, start).Format(dateLayout)
+	return uc.repo.Create(ctx, rule)
+}
+
+// Update — изменить правило. Расписание пересчитывается от сегодняшнего дня:
+// прошлые срабатывания не создаются заново, уже созданные не дублируются.
+func (uc *RecurringUseCase) Update(ctx context.Context, rule entity.RecurringRule) (entity.RecurringRule, error) {
+	current, err := uc.repo.GetByID(ctx, rule.ID, rule.UserID)
+	if err != nil {
+		return entity.RecurringRule{}, err
+	}
+	if err := uc.prepare(ctx, &rule); err != nil {
+		return entity.RecurringRule{}, err
+	}
+	rule.Paused = current.Paused
+	rule.NextDate = uc.upcoming(rule).Format(dateLayout)
+	return uc.repo.Update(ctx, rule)
+}
+
+// Delete — удалить правило.
+func (uc *RecurringUseCase) Delete(ctx context.Context, id, userID int) error {
+	return uc.repo.Delete(ctx, id, userID)
+}
+
+// Pause — приостановить правило: пока оно на паузе, транзакции не создаются.
+func (uc *RecurringUseCase) Pause(ctx context.Context, id, userID int) (entity.RecurringRule, error) {
+	rule, err := uc.repo.GetByID(ctx, id, userID)
+	if err != nil {
+		return entity.RecurringRule{}, err
+	}
+	rule.Paused = true
+	return uc.repo.Update(ctx, rule)
+}
+
+// Resume — возобновить правило. Срабатывания за время паузы не создаются.
+func (uc *RecurringUseCase) Resume(ctx context.Context, id, userID int) (entity.RecurringRule, error) {
+	rule, err := uc.repo.GetByID(ctx, id, userID)
+	if err != nil {
+		return entity.RecurringRule{}, err
+	}
+	if !rule.Paused {
+		return rule, nil
+	}
+	rule.Paused = false
+	rule.NextDate = uc.upcoming(rule).Format(dateLayout)
+	return uc.repo.Update(ctx, rule)
+}
+
+// Skip — пропустить одно будущее срабатывание правила (например, отпуск без аренды).
+// Приостановленное правило не срабатывает, а даты после end_date не наступят — их пропуск
+// отклоняется с ErrNotScheduled.
+func (uc *RecurringUseCase) Skip(ctx context.Context, id, userID int, date string) error {
+	rule, err := uc.repo.GetByID(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if rule.Paused {
+		return ErrNotScheduled
+	}
+
+	target, err := time.Parse(dateLayout, date)
+	if err != nil || ended(rule, target) {
+		return ErrNotScheduled
+	}
+	d, err := time.Parse(dateLayout, rule.NextDate)
+	if err != nil {
+		return err
+	}
+	for d.Before(target) {
+		d = nextOccurrence(rule, d)
+	}
+	if !d.Equal(target) {
+		return ErrNotScheduled
+	}
+
+	return uc.repo.Skip(ctx, rule.ID, date)
+}
+
+// ProcessDue создаёт транзакции для всех наступивших срабатываний.
+// После простоя догоняет все пропущенные даты; повторный запуск безопасен —
+// каждое срабатывание обрабатывается ровно один раз (см. RecurringRepository.Materialize).
+func (uc *RecurringUseCase) ProcessDue(ctx context.Context) {
+	today := uc.today()
+	rules, err := uc.repo.GetDue(ctx, today.Format(dateLayout))
+	if err != nil {
+		log.Println("Ошибка получения повторяющихся операций:", err)
+		return
+	}
+
+	created := 0
+	for _, rule := range rules {
+		for {
+			d, err := time.Parse(dateLayout, rule.NextDate)
+			if err != nil || d.After(today) || ended(rule, d) {
+				break
+			}
+
+			next := nextOccurrence(rule, d).Format(dateLayout)
+			ok, err := uc.repo.Materialize(ctx, rule, next)
+			if err != nil {
+				if ctx.Err() != nil {
+					log.Println("Обработка повторяющихся операций прервана:", ctx.Err())
+					return
+				}
+				log.Println("Ошибка создания повторяющейся операции", rule.ID, ":", err)
+				break
+			}
+			if ok {
+				created++
+			}
+			rule.NextDate = next
+		}
+	}
+
+	if created > 0 {
+		log.Println("Создано повторяющихся операций:", created)
+	}
+}
+
+// RunScheduler обрабатывает повторяющиеся операции каждый час, пока не отменён ctx.
+// Первый проход выполняется сразу, далее — по таймеру. Возвращается после отмены ctx,
+// дождавшись завершения текущего прохода.
+func (uc *RecurringUseCase) RunScheduler(ctx context.Context) {
+	uc.ProcessDue(ctx)
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			uc.ProcessDue(ctx)
+		}
+	}
+}
+
+// prepare — проверить расписание, право вести операции по счёту, категорию и комментарий,
+// округлить сумму по валюте счёта.
+func (uc *RecurringUseCase) prepare(ctx context.Context, rule *entity.RecurringRule) error {
+	if err := canEditAccount(ctx, uc.accountRepo, rule.AccountID, rule.UserID); err != nil {
+		return err
+	}
+
+	currency, err := uc.accountRepo.GetCurrency(ctx, rule.AccountID)
+	if err != nil {
+		return err
+	}
+	rule.Amount = rule.Amount.Round(currency)
+	if err := checkAmount(rule.Amount); err != nil {
+		return err
+	}
+	if err := checkComment(rule.Comment); err != nil {
+		return err
+	}
+	if err := newCategoryChecker(uc.categoryRepo, rule.UserID).check(ctx, rule.CategoryID); err != nil {
+		return err
+	}
+
+	if rule.StartDate == "" {
+		rule.StartDate = uc.today().Format(dateLayout)
+	}
+	start, err := time.Parse(dateLayout, rule.StartDate)
+	if err != nil || checkDateRange(start, uc.today()) != nil {
+		return ErrInvalidSchedule
+	}
+	if rule.EndDate != nil {
+		end, err := time.Parse(dateLayout, *rule.EndDate)
+		if err != nil || end.Before(start) {
+			return ErrInvalidSchedule
+		}
+	}
+
+	switch rule.Frequency {
+	case entity.FrequencyDaily, entity.FrequencyWeekly, entity.FrequencyYearly:
+		rule.DayOfMonth = 0
+	case entity.FrequencyMonthly:
+		if rule.DayOfMonth == 0 {
+			rule.DayOfMonth = start.Day()
+		}
+		if rule.DayOfMonth < 1 || rule.DayOfMonth > 31 {
+			return ErrInvalidSchedule
+		}
+	default:
+		return ErrInvalidSchedule
+	}
+	return nil
+}
+
+// today — сегодняшняя дата (без времени) в UTC.
+func (uc *RecurringUseCase) today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// upcoming — первое срабатывание не раньше сегодняшнего дня и не раньше start_date.
+func (uc *RecurringUseCase) upcoming(rule entity.RecurringRule) time.Time {
+	start, _ := time.Parse(dateLayout, rule.StartDate)
+	d := firstOccurrence(rule, start)
+	today := uc.today()
+	for d.Before(today) {
+		d = nextOccurrence(rule, d)
+	}
+	return d
+}
+
+// ended — дата d позже даты окончания правила.
+func ended(rule entity.RecurringRule, d time.Time) bool {
+	if rule.EndDate == nil {
+		return false
+	}
+	end, err := time.Parse(dateLayout, *rule.EndDate)
+	return err == nil && d.After(end)
+}
+
+// firstOccurrence — первая дата срабатывания не раньше start.
+func firstOccurrence(rule entity.RecurringRule, start time.Time) time.Time {
+	if rule.Frequency != entity.FrequencyMonthly {
+		return start
+	}
+	d := dayInMonth(start.Year(), start.Month(), rule.DayOfMonth)
+	if d.Before(start) {
+		d = dayInMonth(start.Year(), start.Month()+1, rule.DayOfMonth)
+	}
+	return d
+}
+
+// nextOccurrence — следующая после d дата срабатывания.
+func nextOccurrence(rule entity.RecurringRule, d time.Time) time.Time {
+	switch rule.Frequency {
+	case entity.FrequencyWeekly:
+		return d.AddDate(0, 0, 7)
+	case entity.FrequencyMonthly:
+		return dayInMonth(d.Year(), d.Month()+1, rule.DayOfMonth)
+	case entity.FrequencyYearly:
+		start, _ := time.Parse(dateLayout, rule.StartDate)
+		return dayInMonth(d.Year()+1, start.Month(), start.Day())
+	default:
+		return d.AddDate(0, 0, 1)
+	}
+}
+
+// dayInMonth — день day месяца; если в месяце меньше дней — его последний день (31 → 30 апреля, 29 февраля → 28).
+func dayInMonth(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, time.UTC)
+}
@@ -1,23 +1,71 @@
 package usecase
 
-import "vue-calc/internal/entity"
+import (
+	"context"
+
+	"vue-calc/internal/entity"
+)
 
 // StatisticsRepository — интерфейс репозитория статистики.
 type StatisticsRepository interface {
-	GetStatistics(userID int, from, to string, accountID *int, targetCurrency string) (entity.StatisticsResponse, error)
+	GetStatistics(ctx context.Context, userID int, filter entity.StatisticsFilter) (entity.StatisticsResponse, error)
+	GetExpenseByCategory(ctx context.Context, userID int, from, to string, targetCurrency string) ([]entity.CategoryStat, error)
 }
 
 // StatisticsUseCase — бизнес-логика для получения статистики.
 type StatisticsUseCase struct {
-	repo StatisticsRepository
+	repo     StatisticsRepository
+	rateRepo RateRepository
 }
 
 // NewStatisticsUseCase — конструктор юзкейса статистики.
-func NewStatisticsUseCase(repo StatisticsRepository) *StatisticsUseCase {
-	return &StatisticsUseCase{repo: repo}
+func NewStatisticsUseCase(repo StatisticsRepository, rateRepo RateRepository) *StatisticsUseCase {
+	return &StatisticsUseCase{repo: repo, rateRepo: rateRepo}
 }
 
 // GetStatistics — получить агрегированную статистику за период в указанной валюте.
-func (uc *StatisticsUseCase) GetStatistics(userID int, from, to string, accountID *int, targetCurrency string) (entity.StatisticsResponse, error) {
-	return uc.repo.GetStatistics(userID, from, to, accountID, targetCurrency)
+// Depth > 0 — статистика по категориям деревом: итог каждой категории включает подкатегории,
+// показываются уровни до Depth включительно; Depth == 0 — плоский список.
+// Tags — учитываются только операции со всеми перечисленными тегами.
+// Все суммы округляются до минимальной единицы целевой валюты; для валюты без курса — ErrUnknownCurrency.
+func (uc *StatisticsUseCase) GetStatistics(ctx context.Context, userID int, filter entity.StatisticsFilter) (entity.StatisticsResponse, error) {
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return entity.StatisticsResponse{}, err
+	}
+	filter.Tags = tags
+	if err := checkCurrency(ctx, uc.rateRepo, filter.Currency); err != nil {
+		return entity.StatisticsResponse{}, err
+	}
+
+	stats, err := uc.repo.GetStatistics(ctx, userID, filter)
+	if err != nil {
+		return stats, err
+	}
+
+	targetCurrency := filter.Currency
+
+	stats.TotalIncome = stats.TotalIncome.Round(targetCurrency)
+	stats.TotalExpense = stats.TotalExpense.Round(targetCurrency)
+	roundCategoryStats(stats.IncomeByCategory, targetCurrency)
+	roundCategoryStats(stats.ExpenseByCategory, targetCurrency)
+	for i := range stats.IncomeByTag {
+		stats.IncomeByTag[i].Total = stats.IncomeByTag[i].Total.Round(targetCurrency)
+	}
+	for i := range stats.ExpenseByTag {
+		stats.ExpenseByTag[i].Total = stats.ExpenseByTag[i].Total.Round(targetCurrency)
+	}
+	for i := range stats.DailyStats {
+		stats.DailyStats[i].Income = stats.DailyStats[i].Income.Round(targetCurrency)
+		stats.DailyStats[i].Expense = stats.DailyStats[i].Expense.Round(targetCurrency)
+	}
+	return stats, nil
+}
+
+// roundCategoryStats — округлить суммы категорий и всех их подкатегорий.
+func roundCategoryStats(stats []entity.CategoryStat, currency string) {
+	for i := range stats {
+		stats[i].Total = stats[i].Total.Round(currency)
+		roundCategoryStats(stats[i].Children, currency)
+	}
 }
@@ -0,0 +1,56 @@
+package usecase
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"unicode"
+
+	"vue-calc/internal/entity"
+)
+
+// ErrInvalidTag — пустой тег или тег с пробелами и запятыми.
+var ErrInvalidTag = entity.NewValidationError("invalid_tag", "tags", "Неверный тег")
+
+// TagRepository — интерфейс репозитория тегов.
+type TagRepository interface {
+	GetAllByUserID(ctx context.Context, userID int) ([]entity.Tag, error)
+}
+
+// TagUseCase — бизнес-логика тегов операций.
+type TagUseCase struct {
+	repo TagRepository
+}
+
+// NewTagUseCase — конструктор юзкейса тегов.
+func NewTagUseCase(repo TagRepository) *TagUseCase {
+	return &TagUseCase{repo: repo}
+}
+
+// GetAll — теги пользователя и его совместных счетов с числом операций; самые используемые — первыми.
+func (uc *TagUseCase) GetAll(ctx context.Context, userID int) ([]entity.Tag, error) {
+	return uc.repo.GetAllByUserID(ctx, userID)
+}
+
+// normalizeTags — привести теги к виду, в котором они хранятся: без ведущего #,
+// в нижнем регистре, без повторов и по алфавиту. nil остаётся nil («теги не переданы»).
+func normalizeTags(tags []string) ([]string, error) {
+	if tags == nil {
+		return nil, nil
+	}
+
+	seen := map[string]bool{}
+	result := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || strings.ContainsFunc(tag, func(r rune) bool { return unicode.IsSpace(r) || r == ',' || r == '#' }) {
+			return nil, ErrInvalidTag
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			result = append(result, tag)
+		}
+	}
+	sort.Strings(result)
+	return result, nil
+}
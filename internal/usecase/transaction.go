@@ -1,42 +1,336 @@
 package usecase
 
-import "vue-calc/internal/entity"
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"vue-calc/internal/entity"
+)
+
+// Размер страницы истории операций: по умолчанию и максимально допустимый.
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+var (
+	// ErrInvalidCursor — курсор повреждён или выдан для другой сортировки.
+	ErrInvalidCursor = entity.NewValidationError("invalid_cursor", "cursor", "Неверный cursor")
+	// ErrInvalidSort — неизвестный вариант сортировки.
+	ErrInvalidSort = entity.NewValidationError("invalid_sort", "sort", "Неверный sort")
+	// ErrInvalidSplit — часть операции с нулевой суммой или другого знака, чем операция.
+	ErrInvalidSplit = entity.NewValidationError("invalid_split", "splits", "Сумма каждой части должна быть ненулевой и того же знака, что и операция")
+	// ErrSplitSum — сумма частей не равна сумме операции.
+	ErrSplitSum = entity.NewValidationError("split_sum", "splits", "Сумма частей должна быть равна сумме операции")
+)
 
 // TransactionRepository — интерфейс репозитория транзакций.
 // Определяет контракт для слоя данных.
 type TransactionRepository interface {
-	GetByAccountID(accountID int) ([]entity.Transaction, error)
-	Create(transaction entity.Transaction) (entity.Transaction, error)
-	Delete(id, accountID int) error
-	Update(id, accountID int, transaction entity.Transaction) (entity.Transaction, error)
+	GetByAccountID(ctx context.Context, accountID int, filter entity.TransactionFilter) ([]entity.Transaction, error)
+	Create(ctx context.Context, transaction entity.Transaction) (entity.Transaction, error)
+	CreateBatch(ctx context.Context, transactions []entity.Transaction, newCategories []entity.Category) ([]entity.Transaction, error)
+	Delete(ctx context.Context, id, accountID, userID int) error
+	Update(ctx context.Context, id, accountID, userID int, transaction entity.Transaction) (entity.Transaction, error)
+	CreateTransfer(ctx context.Context, transfer entity.Transfer) (entity.Transfer, error)
+	GetByID(ctx context.Context, id, accountID int) (entity.Transaction, error)
 }
 
 // TransactionUseCase — бизнес-логика для работы с транзакциями (операциями по счетам).
 type TransactionUseCase struct {
-	repo TransactionRepository
+	repo         TransactionRepository
+	accountRepo  AccountRepository
+	categoryRepo CategoryRepository
+	rateRepo     RateRepository
 }
 
 // NewTransactionUseCase — конструктор юзкейса транзакций.
-func NewTransactionUseCase(repo TransactionRepository) *TransactionUseCase {
-	return &TransactionUseCase{repo: repo}
+func NewTransactionUseCase(repo TransactionRepository, accountRepo AccountRepository, categoryRepo CategoryRepository, rateRepo RateRepository) *TransactionUseCase {
+	return &TransactionUseCase{repo: repo, accountRepo: accountRepo, categoryRepo: categoryRepo, rateRepo: rateRepo}
 }
 
-// GetByAccountID — получить все транзакции по счёту (новые сверху).
-func (uc *TransactionUseCase) GetByAccountID(accountID int) ([]entity.Transaction, error) {
-	return uc.repo.GetByAccountID(accountID)
+// GetByAccountID — получить страницу транзакций по счёту.
+// cursor — значение next_cursor из предыдущей страницы (пусто — первая страница).
+func (uc *TransactionUseCase) GetByAccountID(ctx context.Context, accountID int, filter entity.TransactionFilter, cursor string) (entity.TransactionPage, error) {
+	switch filter.Sort {
+	case "":
+		filter.Sort = entity.SortDateDesc
+	case entity.SortDateDesc, entity.SortDateAsc, entity.SortAmountDesc, entity.SortAmountAsc:
+	default:
+		return entity.TransactionPage{}, ErrInvalidSort
+	}
+
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return entity.TransactionPage{}, err
+	}
+	filter.Tags = tags
+
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageLimit
+	}
+	if filter.Limit > maxPageLimit {
+		filter.Limit = maxPageLimit
+	}
+
+	if cursor != "" {
+		c, err := decodeCursor(cursor, filter.Sort)
+		if err != nil {
+			return entity.TransactionPage{}, err
+		}
+		filter.Cursor = &c
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница.
+	pageLimit := filter.Limit
+	filter.Limit++
+	items, err := uc.repo.GetByAccountID(ctx, accountID, filter)
+	if err != nil {
+		return entity.TransactionPage{}, err
+	}
+
+	page := entity.TransactionPage{Items: items}
+	if len(items) > pageLimit {
+		page.Items = items[:pageLimit]
+		page.NextCursor = encodeCursor(page.Items[pageLimit-1], filter.Sort)
+	}
+	return page, nil
 }
 
 // Create — создать новую транзакцию (пополнение или списание).
-func (uc *TransactionUseCase) Create(transaction entity.Transaction) (entity.Transaction, error) {
-	return uc.repo.Create(transaction)
+// Сумма округляется до минимальной единицы валюты счёта, теги нормализуются (см. normalizeTags),
+// разбивка проверяется (см. checkSplits). Категории должны принадлежать автору операции (CreatedBy).
+func (uc *TransactionUseCase) Create(ctx context.Context, transaction entity.Transaction) (entity.Transaction, error) {
+	if err := uc.prepare(ctx, &transaction, newCategoryChecker(uc.categoryRepo, authorID(transaction))); err != nil {
+		return entity.Transaction{}, err
+	}
+	return uc.repo.Create(ctx, transaction)
+}
+
+// CreateBatch — создать пачку транзакций атомарно (например, при импорте выписки).
+// Каждая транзакция проходит те же проверки, что и в Create (см. PrepareBatch); при первой
+// ошибке не сохраняется ничего. newCategories — уже проверенные новые категории, создаваемые
+// вместе с операциями (см. TransactionRepository.CreateBatch): если операции не сохранятся,
+// не останется и категорий.
+func (uc *TransactionUseCase) CreateBatch(ctx context.Context, transactions []entity.Transaction, newCategories []entity.Category) ([]entity.Transaction, error) {
+	invalid, err := uc.PrepareBatch(ctx, transactions)
+	if err != nil {
+		return nil, err
+	}
+	for _, err := range invalid {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return uc.repo.CreateBatch(ctx, transactions, newCategories)
+}
+
+// PrepareBatch — подготовить и проверить пачку транзакций так же, как Create, ничего не сохраняя
+// (например, для предпросмотра импорта). Транзакции изменяются на месте: суммы округляются,
+// теги нормализуются. invalid[i] — ошибка валидации i-й транзакции (nil — транзакция в порядке);
+// err — ошибка, не связанная с данными отдельной транзакции (счёт не найден, сбой БД).
+func (uc *TransactionUseCase) PrepareBatch(ctx context.Context, transactions []entity.Transaction) (invalid []error, err error) {
+	invalid = make([]error, len(transactions))
+	currencies := map[int]string{}
+	checkers := map[int]*categoryChecker{}
+	for i := range transactions {
+		t := &transactions[i]
+		currency, ok := currencies[t.AccountID]
+		if !ok {
+			if currency, err = uc.accountCurrency(ctx, t.AccountID); err != nil {
+				return nil, err
+			}
+			currencies[t.AccountID] = currency
+		}
+		checker, ok := checkers[authorID(*t)]
+		if !ok {
+			checker = newCategoryChecker(uc.categoryRepo, authorID(*t))
+			checkers[authorID(*t)] = checker
+		}
+		if err := prepareTransaction(ctx, t, currency, checker); err != nil {
+			var domainErr *entity.Error
+			if !errors.As(err, &domainErr) {
+				return nil, err
+			}
+			invalid[i] = err
+		}
+	}
+	return invalid, nil
+}
+
+// Delete — удалить транзакцию по ID от имени пользователя userID (он попадает в журнал изменений).
+func (uc *TransactionUseCase) Delete(ctx context.Context, id, accountID, userID int) error {
+	return uc.repo.Delete(ctx, id, accountID, userID)
+}
+
+// Update — обновить транзакцию по ID от имени пользователя userID (его категории можно назначить).
+// Категории, уже стоящие на операции или её частях, сохраняются, даже если принадлежат
+// другому участнику совместного счёта. Дата обязательна. Если теги или разбивка не переданы (nil),
+// они не меняются; сохранённая разбивка при этом должна сходиться с новой суммой.
+// Половину перевода изменить нельзя (entity.ErrTransferImmutable): перевод удаляют и создают заново.
+func (uc *TransactionUseCase) Update(ctx context.Context, id, accountID, userID int, transaction entity.Transaction) (entity.Transaction, error) {
+	if transaction.CreatedAt == "" {
+		return entity.Transaction{}, ErrInvalidDate
+	}
+	stored, err := uc.repo.GetByID(ctx, id, accountID)
+	if err != nil {
+		return entity.Transaction{}, err
+	}
+	if stored.TransferID != nil {
+		return entity.Transaction{}, entity.ErrTransferImmutable
+	}
+
+	categories := newCategoryChecker(uc.categoryRepo, userID)
+	categories.allow(stored.CategoryID)
+	for _, s := range stored.Splits {
+		categories.allow(s.CategoryID)
+	}
+	transaction.AccountID = accountID
+	if err := uc.prepare(ctx, &transaction, categories); err != nil {
+		return entity.Transaction{}, err
+	}
+
+	if transaction.Splits == nil && len(stored.Splits) > 0 {
+		var sum entity.Money
+		for _, s := range stored.Splits {
+			sum += s.Amount
+		}
+		if sum != transaction.Amount {
+			return entity.Transaction{}, ErrSplitSum
+		}
+		transaction.CategoryID = nil
+	}
+	return uc.repo.Update(ctx, id, accountID, userID, transaction)
+}
+
+// prepare — подготовить транзакцию к сохранению в валюте её счёта (см. prepareTransaction);
+// категории проверяет categories.
+func (uc *TransactionUseCase) prepare(ctx context.Context, transaction *entity.Transaction, categories *categoryChecker) error {
+	currency, err := uc.accountCurrency(ctx, transaction.AccountID)
+	if err != nil {
+		return err
+	}
+	return prepareTransaction(ctx, transaction, currency, categories)
 }
 
-// Delete — удалить транзакцию по ID.
-func (uc *TransactionUseCase) Delete(id, accountID int) error {
-	return uc.repo.Delete(id, accountID)
+// accountCurrency — валюта счёта; для неё должен быть курс, иначе операцию не учесть в статистике.
+func (uc *TransactionUseCase) accountCurrency(ctx context.Context, accountID int) (string, error) {
+	currency, err := uc.accountRepo.GetCurrency(ctx, accountID)
+	if err != nil {
+		return "", err
+	}
+	if err := checkCurrency(ctx, uc.rateRepo, currency); err != nil {
+		return "", err
+	}
+	return currency, nil
 }
 
-// Update — обновить транзакцию по ID.
-func (uc *TransactionUseCase) Update(id, accountID int, transaction entity.Transaction) (entity.Transaction, error) {
-	return uc.repo.Update(id, accountID, transaction)
+// prepareTransaction — округлить сумму транзакции по правилам ISO 4217 для валюты currency
+// и проверить её: сумма, дата, комментарий, теги, категории (через categories) и разбивка.
+func prepareTransaction(ctx context.Context, transaction *entity.Transaction, currency string, categories *categoryChecker) error {
+	transaction.Amount = transaction.Amount.Round(currency)
+	if err := checkAmount(transaction.Amount); err != nil {
+		return err
+	}
+	if err := checkDate(transaction.CreatedAt, time.Now()); err != nil {
+		return err
+	}
+	transaction.Comment = strings.TrimSpace(transaction.Comment)
+	if err := checkComment(transaction.Comment); err != nil {
+		return err
+	}
+
+	tags, err := normalizeTags(transaction.Tags)
+	if err != nil {
+		return err
+	}
+	transaction.Tags = tags
+
+	if err := categories.check(ctx, transaction.CategoryID); err != nil {
+		return err
+	}
+	for _, s := range transaction.Splits {
+		if err := categories.check(ctx, s.CategoryID); err != nil {
+			return err
+		}
+	}
+
+	return checkSplits(transaction, currency)
+}
+
+// authorID — автор операции (0, если не указан: такому пользователю не принадлежит ни одна категория).
+func authorID(transaction entity.Transaction) int {
+	if transaction.CreatedBy == nil {
+		return 0
+	}
+	return *transaction.CreatedBy
+}
+
+// checkSplits — округлить суммы частей и проверить, что каждая того же знака, что и операция,
+// а вместе они дают сумму операции. У разбитой операции категория только у частей.
+func checkSplits(transaction *entity.Transaction, currency string) error {
+	if len(transaction.Splits) == 0 {
+		return nil
+	}
+
+	var sum entity.Money
+	for i := range transaction.Splits {
+		s := &transaction.Splits[i]
+		s.Amount = s.Amount.Round(currency)
+		s.Comment = strings.TrimSpace(s.Comment)
+		if err := checkComment(s.Comment); err != nil {
+			return err
+		}
+		if s.Amount == 0 || (s.Amount > 0) != (transaction.Amount > 0) {
+			return ErrInvalidSplit
+		}
+		sum += s.Amount
+	}
+	if sum != transaction.Amount {
+		return ErrSplitSum
+	}
+	transaction.CategoryID = nil
+	return nil
+}
+
+// encodeCursor — курсор указывает на последнюю операцию страницы.
+// Формат до base64: "сортировка|значение ключа|id".
+func encodeCursor(t entity.Transaction, sort string) string {
+	value := t.CreatedAt
+	if sort == entity.SortAmountDesc || sort == entity.SortAmountAsc {
+		value = t.Amount.String()
+	}
+	raw := sort + "|" + value + "|" + strconv.Itoa(t.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// decodeCursor — разобрать курсор и проверить, что он выдан для той же сортировки.
+func decodeCursor(cursor, sort string) (entity.TransactionCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return entity.TransactionCursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 || parts[0] != sort || parts[1] == "" {
+		return entity.TransactionCursor{}, ErrInvalidCursor
+	}
+
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return entity.TransactionCursor{}, ErrInvalidCursor
+	}
+	if sort == entity.SortAmountDesc || sort == entity.SortAmountAsc {
+		_, err = entity.ParseMoney(parts[1])
+	} else {
+		_, err = time.Parse(time.RFC3339Nano, parts[1])
+	}
+	if err != nil {
+		return entity.TransactionCursor{}, ErrInvalidCursor
+	}
+	return entity.TransactionCursor{Value: parts[1], ID: id}, nil
 }
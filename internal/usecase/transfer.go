@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"vue-calc/internal/entity"
+)
+
+var (
+	// ErrSameAccount — перевод на тот же самый счёт не имеет смысла.
+	ErrSameAccount = entity.NewValidationError("same_account", "to_account_id", "Счёт списания и счёт зачисления совпадают")
+	// ErrInvalidAmount — сумма перевода должна быть положительной.
+	ErrInvalidAmount = entity.NewValidationError("invalid_amount", "amount", "Сумма перевода должна быть больше нуля")
+	// ErrRateRequired — для валюты счёта нет курса в таблице rates, курс нужно передать явно.
+	ErrRateRequired = entity.NewValidationError("rate_required", "rate", "Курс валюты не найден, укажите rate")
+)
+
+// TransferUseCase — бизнес-логика переводов между счетами пользователя.
+type TransferUseCase struct {
+	txRepo      TransactionRepository
+	accountRepo AccountRepository
+	rateRepo    RateRepository
+}
+
+// NewTransferUseCase — конструктор юзкейса переводов.
+func NewTransferUseCase(txRepo TransactionRepository, accountRepo AccountRepository, rateRepo RateRepository) *TransferUseCase {
+	return &TransferUseCase{txRepo: txRepo, accountRepo: accountRepo, rateRepo: rateRepo}
+}
+
+// Create — перевести деньги с одного счёта пользователя на другой.
+// Оба счёта должны быть доступны пользователю на изменение (свои или совместные с ролью editor).
+// Если валюты счетов различаются, сумма зачисления пересчитывается по курсу:
+// переданному пользователем (transfer.Rate > 0) или из таблицы rates.
+func (uc *TransferUseCase) Create(ctx context.Context, transfer entity.Transfer) (entity.Transfer, error) {
+	if transfer.FromAccountID == transfer.ToAccountID {
+		return entity.Transfer{}, ErrSameAccount
+	}
+
+	from, err := uc.accountRepo.GetByID(ctx, transfer.FromAccountID, transfer.UserID)
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+	to, err := uc.accountRepo.GetByID(ctx, transfer.ToAccountID, transfer.UserID)
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+	if from.Role == entity.RoleViewer || to.Role == entity.RoleViewer {
+		return entity.Transfer{}, ErrReadOnlyAccount
+	}
+
+	transfer.Amount = transfer.Amount.Round(from.Currency)
+	if transfer.Amount <= 0 {
+		return entity.Transfer{}, ErrInvalidAmount
+	}
+	if err := checkAmount(transfer.Amount); err != nil {
+		return entity.Transfer{}, err
+	}
+	if err := checkDate(transfer.CreatedAt, time.Now()); err != nil {
+		return entity.Transfer{}, err
+	}
+	if err := checkComment(transfer.Comment); err != nil {
+		return entity.Transfer{}, err
+	}
+
+	if transfer.Rate <= 0 {
+		transfer.Rate, err = uc.rate(ctx, from.Currency, to.Currency)
+		if err != nil {
+			return entity.Transfer{}, err
+		}
+	}
+
+	toAmount, err := transfer.Amount.Convert(transfer.Rate)
+	if err != nil {
+		return entity.Transfer{}, err
+	}
+
+	transfer.From = entity.Transaction{
+		AccountID: from.ID,
+		Amount:    -transfer.Amount,
+		Comment:   transfer.Comment,
+		CreatedBy: &transfer.UserID,
+	}
+	transfer.To = entity.Transaction{
+		AccountID: to.ID,
+		Amount:    toAmount.Round(to.Currency),
+		Comment:   transfer.Comment,
+		CreatedBy: &transfer.UserID,
+	}
+
+	return uc.txRepo.CreateTransfer(ctx, transfer)
+}
+
+// rate — курс пересчёта из валюты from в валюту to через курсы к USD.
+func (uc *TransferUseCase) rate(ctx context.Context, from, to string) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	src, err := uc.rateRepo.GetByCurrency(ctx, from)
+	if errors.Is(err, entity.ErrRateNotFound) {
+		return 0, ErrRateRequired
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	dst, err := uc.rateRepo.GetByCurrency(ctx, to)
+	if errors.Is(err, entity.ErrRateNotFound) {
+		return 0, ErrRateRequired
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if dst.RateToUSD == 0 {
+		return 0, ErrRateRequired
+	}
+	return src.RateToUSD / dst.RateToUSD, nil
+}
@@ -0,0 +1,136 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"vue-calc/internal/entity"
+)
+
+// ErrAccountDeleted — операцию нельзя восстановить, пока её счёт в корзине.
+var ErrAccountDeleted = entity.NewError(entity.KindConflict, "account_deleted", "Счёт операции удалён, сначала восстановите счёт")
+
+// TrashRepository — интерфейс репозитория корзины.
+type TrashRepository interface {
+	GetAccounts(ctx context.Context, userID int) ([]entity.TrashedAccount, error)
+	GetCategories(ctx context.Context, userID int) ([]entity.TrashedCategory, error)
+	GetTransactions(ctx context.Context, userID int) ([]entity.TrashedTransaction, error)
+	GetTransactionAccount(ctx context.Context, id, userID int) (int, error)
+	RestoreAccount(ctx context.Context, id, userID int) error
+	RestoreCategory(ctx context.Context, id, userID int) error
+	RestoreTransaction(ctx context.Context, id, userID int) error
+	PurgeAccount(ctx context.Context, id, userID int) ([]string, error)
+	PurgeCategory(ctx context.Context, id, userID int) error
+	PurgeTransaction(ctx context.Context, id, userID int) ([]string, error)
+}
+
+// TrashUseCase — бизнес-логика корзины удалённых объектов.
+type TrashUseCase struct {
+	repo         TrashRepository
+	accountRepo  AccountRepository
+	categoryRepo CategoryRepository
+	storage      FileStorage
+}
+
+// NewTrashUseCase — конструктор юзкейса корзины. storage — хранилище вложений,
+// файлы которых удаляются вместе с операциями.
+func NewTrashUseCase(repo TrashRepository, accountRepo AccountRepository, categoryRepo CategoryRepository, storage FileStorage) *TrashUseCase {
+	return &TrashUseCase{repo: repo, accountRepo: accountRepo, categoryRepo: categoryRepo, storage: storage}
+}
+
+// Get — содержимое корзины пользователя.
+func (uc *TrashUseCase) Get(ctx context.Context, userID int) (entity.Trash, error) {
+	var trash entity.Trash
+	var err error
+	if trash.Accounts, err = uc.repo.GetAccounts(ctx, userID); err != nil {
+		return entity.Trash{}, err
+	}
+	if trash.Categories, err = uc.repo.GetCategories(ctx, userID); err != nil {
+		return entity.Trash{}, err
+	}
+	if trash.Transactions, err = uc.repo.GetTransactions(ctx, userID); err != nil {
+		return entity.Trash{}, err
+	}
+	return trash, nil
+}
+
+// RestoreAccount — восстановить счёт вместе с операциями, удалёнными при его удалении.
+func (uc *TrashUseCase) RestoreAccount(ctx context.Context, id, userID int) error {
+	return uc.repo.RestoreAccount(ctx, id, userID)
+}
+
+// RestoreCategory — восстановить категорию. Если за это время создана категория
+// с тем же названием, восстановление отклоняется (entity.ErrCategoryExists).
+func (uc *TrashUseCase) RestoreCategory(ctx context.Context, id, userID int) error {
+	trashed, err := uc.repo.GetCategories(ctx, userID)
+	if err != nil {
+		return err
+	}
+	live, err := uc.categoryRepo.GetAllByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, t := range trashed {
+		if t.ID != id {
+			continue
+		}
+		for _, c := range live {
+			if strings.EqualFold(c.Name, t.Name) {
+				return entity.ErrCategoryExists
+			}
+		}
+	}
+	return uc.repo.RestoreCategory(ctx, id, userID)
+}
+
+// RestoreTransaction — восстановить операцию. Счёт операции должен быть не удалён,
+// а пользователь — его владельцем или редактором (ErrReadOnlyAccount для просмотра).
+func (uc *TrashUseCase) RestoreTransaction(ctx context.Context, id, userID int) error {
+	accountID, err := uc.repo.GetTransactionAccount(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	// Доступ к счёту уже проверен по корзине, поэтому «не найден» здесь — счёт удалён.
+	if err := canEditAccount(ctx, uc.accountRepo, accountID, userID); err != nil {
+		if errors.Is(err, entity.ErrAccountNotFound) {
+			return ErrAccountDeleted
+		}
+		return err
+	}
+	return uc.repo.RestoreTransaction(ctx, id, userID)
+}
+
+// PurgeAccount — окончательно удалить счёт из корзины вместе с файлами вложений его операций.
+func (uc *TrashUseCase) PurgeAccount(ctx context.Context, id, userID int) error {
+	keys, err := uc.repo.PurgeAccount(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	deleteStoredFiles(uc.storage, keys...)
+	return nil
+}
+
+// PurgeCategory — окончательно удалить категорию из корзины.
+func (uc *TrashUseCase) PurgeCategory(ctx context.Context, id, userID int) error {
+	return uc.repo.PurgeCategory(ctx, id, userID)
+}
+
+// PurgeTransaction — окончательно удалить операцию из корзины вместе с файлами вложений.
+// Удалять может владелец или редактор счёта (ErrReadOnlyAccount для просмотра).
+func (uc *TrashUseCase) PurgeTransaction(ctx context.Context, id, userID int) error {
+	accountID, err := uc.repo.GetTransactionAccount(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	// Если счёт сам в корзине, роль не определить — права проверяет репозиторий.
+	if err := canEditAccount(ctx, uc.accountRepo, accountID, userID); err != nil && !errors.Is(err, entity.ErrAccountNotFound) {
+		return err
+	}
+	keys, err := uc.repo.PurgeTransaction(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	deleteStoredFiles(uc.storage, keys...)
+	return nil
+}
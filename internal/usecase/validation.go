@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"vue-calc/internal/entity"
+)
+
+// Ограничения на данные, которые вводит пользователь.
+const (
+	// maxCommentLength — максимальная длина комментария в символах.
+	maxCommentLength = 500
+	// maxAmount — максимальная сумма одной операции по модулю: триллион единиц валюты
+	// (Money хранит четыре знака после запятой).
+	maxAmount = entity.Money(1e12 * 1e4)
+	// dateHorizon — насколько вперёд можно записать операцию (запланированные платежи).
+	dateHorizon = 1
+)
+
+// minDate — самая ранняя допустимая дата операции.
+var minDate = time.Date(1970, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// dateLayouts — форматы дат операций: ISO 8601 со смещением (так отдаёт API и присылает фронтенд),
+// без смещения (импорт выписок) и просто дата.
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02 15:04:05", "2006-01-02"}
+
+var (
+	// ErrZeroAmount — сумма операции равна нулю.
+	ErrZeroAmount = entity.NewValidationError("zero_amount", "amount", "Сумма должна быть ненулевой")
+	// ErrCommentTooLong — комментарий длиннее maxCommentLength символов.
+	ErrCommentTooLong = entity.NewValidationError("comment_too_long", "comment", "Комментарий длиннее 500 символов")
+	// ErrInvalidDate — дата не разбирается или лежит вне разумного диапазона.
+	ErrInvalidDate = entity.NewValidationError("invalid_date", "created_at", "Неверная дата: ожидается ISO 8601, не раньше 1970 года и не дальше чем на год вперёд")
+	// ErrInvalidCategory — категория не существует, удалена или принадлежит другому пользователю.
+	ErrInvalidCategory = entity.NewValidationError("invalid_category", "category_id", "Категория не найдена")
+	// ErrUnknownCurrency — для валюты нет курса в таблице rates.
+	ErrUnknownCurrency = entity.NewValidationError("unknown_currency", "currency", "Неизвестная валюта")
+)
+
+// checkAmount — сумма ненулевая и не больше maxAmount по модулю.
+func checkAmount(amount entity.Money) error {
+	if amount == 0 {
+		return ErrZeroAmount
+	}
+	if amount.Abs() > maxAmount {
+		return entity.ErrMoneyOverflow
+	}
+	return nil
+}
+
+// checkComment — комментарий не длиннее maxCommentLength символов.
+func checkComment(comment string) error {
+	if utf8.RuneCountInString(comment) > maxCommentLength {
+		return ErrCommentTooLong
+	}
+	return nil
+}
+
+// checkDate — непустая дата разбирается одним из dateLayouts и лежит между minDate
+// и годом вперёд от now. Пустая дата допустима: её подставит БД.
+func checkDate(date string, now time.Time) error {
+	if date == "" {
+		return nil
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, date); err == nil {
+			return checkDateRange(t, now)
+		}
+	}
+	return ErrInvalidDate
+}
+
+// checkDateRange — дата не раньше minDate и не дальше dateHorizon лет от now.
+func checkDateRange(t, now time.Time) error {
+	if t.Before(minDate) || t.After(now.AddDate(dateHorizon, 0, 0)) {
+		return ErrInvalidDate
+	}
+	return nil
+}
+
+// checkCurrency — для валюты есть курс в таблице rates.
+func checkCurrency(ctx context.Context, rateRepo RateRepository, currency string) error {
+	_, err := rateRepo.GetByCurrency(ctx, currency)
+	if errors.Is(err, entity.ErrRateNotFound) {
+		return ErrUnknownCurrency
+	}
+	return err
+}
+
+// categoryChecker — проверка, что категории принадлежат пользователю.
+// Запоминает уже проверенные категории, чтобы пачка операций не проверяла одну категорию дважды.
+type categoryChecker struct {
+	repo   CategoryRepository
+	userID int
+	owned  map[int]bool
+}
+
+func newCategoryChecker(repo CategoryRepository, userID int) *categoryChecker {
+	return &categoryChecker{repo: repo, userID: userID, owned: map[int]bool{}}
+}
+
+// allow — считать категорию допустимой без проверки владельца (например, уже стоящую на операции).
+func (c *categoryChecker) allow(categoryID *int) {
+	if categoryID != nil {
+		c.owned[*categoryID] = true
+	}
+}
+
+// check — nil, если категория не задана или это не удалённая категория пользователя.
+func (c *categoryChecker) check(ctx context.Context, categoryID *int) error {
+	if categoryID == nil {
+		return nil
+	}
+	owned, ok := c.owned[*categoryID]
+	if !ok {
+		var err error
+		if owned, err = c.repo.Exists(ctx, *categoryID, c.userID); err != nil {
+			return err
+		}
+		c.owned[*categoryID] = owned
+	}
+	if !owned {
+		return ErrInvalidCategory
+	}
+	return nil
+}